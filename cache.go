@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ONSdigital/log.go/v2/log"
+	"golang.org/x/sync/singleflight"
 )
 
 // Cacher defines the required methods to initialise a cache
@@ -21,15 +24,224 @@ type Cacher interface {
 
 // Cache contains all the information to start, update and close caching data
 type Cache struct {
-	data        sync.Map
-	config      Config
-	close       chan struct{}
-	UpdateFuncs map[string]func() (interface{}, error)
+	shards       [numShards]*shard
+	config       Config
+	close        chan struct{}
+	janitorClose chan struct{}
+
+	// mu guards every field below it down to updateLoopStarted.
+	// UpdateFuncs/updateTTLs/lastUpdated/refreshAhead/types are written from
+	// AddUpdateFunc*, RegisterType, GetTyped, loadAndSet and Close, and read
+	// from UpdateContent's worker goroutines, loadAndSet
+	// and dueForRefresh - all of which can run concurrently with each other,
+	// so plain map access here is a data race (the Go runtime will fatal on
+	// a concurrent map write even without -race).
+	mu           sync.Mutex
+	UpdateFuncs  map[string]func(ctx context.Context) (interface{}, error)
+	updateTTLs   map[string]time.Duration
+	lastUpdated  map[string]time.Time
+	refreshAhead map[string]time.Duration
+	types        map[string]*cacheType
+
+	// janitorStarted and updateLoopStarted record whether startJanitor/
+	// StartUpdates actually launched their goroutines, so Close knows
+	// whether anything is listening on janitorClose/close - a cache with
+	// CleanupInterval or UpdateInterval configured but StartUpdates never
+	// called (or called with no update funcs registered) has no such
+	// goroutine, and sending to either channel would block forever.
+	janitorStarted    bool
+	updateLoopStarted bool
+
+	stats          Stats
+	loadGroup      singleflight.Group
+	onEvict        func(key string, value interface{}, reason EvictionReason)
+	onUpdateResult func(key string, err error, stale bool)
+}
+
+// EvictionReason describes why an entry was removed from the cache, passed
+// to a callback registered with Cache.OnEviction.
+type EvictionReason string
+
+// Supported values for EvictionReason.
+const (
+	EvictedExpired  EvictionReason = "expired"
+	EvictedCapacity EvictionReason = "capacity"
+	EvictedManual   EvictionReason = "manual"
+)
+
+// Stats holds counters describing cache activity, incremented from Get, Set
+// and the janitor's expiry sweeps.
+type Stats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+}
+
+// MetricsRecorder receives observability events from a Cache. The `metrics`
+// subpackage provides a Prometheus-backed implementation; services can also
+// provide their own to plug into a different monitoring stack.
+type MetricsRecorder interface {
+	Hit(key string)
+	Miss(key string)
+	UpdateDuration(key string, d time.Duration)
+	UpdateError(key string)
+	Entries(n int)
+}
+
+// entry wraps a cached value with its expiry. A zero expiresAt means the
+// entry never expires, preserving the cache's original "store forever"
+// semantics for values set without a TTL.
+type entry struct {
+	value     interface{}
+	expiresAt time.Time
+
+	// updatedAt and lastErr back Cache.GetWithMeta. lastErr is set by
+	// recordError when a refresh fails without disturbing value or
+	// expiresAt, so Config.ReturnLastGood can keep serving the last good
+	// value while still surfacing that it's gone stale.
+	updatedAt time.Time
+	lastErr   error
+}
+
+func (e entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
 }
 
+// Meta carries metadata about a cache entry alongside its value, returned by
+// Cache.GetWithMeta.
+type Meta struct {
+	LastUpdated time.Time
+	LastError   error
+	Stale       bool
+}
+
+// Supported values for Config.Backend.
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
 // Configs contains all the configurations for the cache
 type Config struct {
 	UpdateInterval *time.Duration
+
+	// CleanupInterval, if set, starts a janitor goroutine alongside
+	// StartUpdates that periodically evicts expired entries, so memory
+	// doesn't grow unbounded when keys are written with a TTL but never
+	// re-read. This is also the only way expired entries are evicted
+	// proactively: with CleanupInterval unset, a TTL'd key is only removed
+	// lazily, the next time something calls Get/GetWithMeta for it, which
+	// means OnEviction(EvictedExpired) won't fire for it until then either -
+	// a key that's written once and never read again stays in memory, and
+	// never reports its own expiry, until CleanupInterval's sweep finds it.
+	// Set CleanupInterval if a service relies on OnEviction firing promptly.
+	CleanupInterval *time.Duration
+
+	// UpdateConcurrency bounds how many update functions UpdateContent runs
+	// at once. If 0, every registered update function is run concurrently.
+	UpdateConcurrency int
+
+	// MaxEntries limits the number of entries the cache holds, split evenly
+	// across its numShards shards with at least 1 each. Once a shard exceeds
+	// its share, that shard's least-recently-used entry is evicted on Set, so
+	// a MaxEntries below numShards still lets the cache hold up to numShards
+	// entries. 0 means unbounded.
+	MaxEntries int
+
+	// MaxBytes limits the total size Sizer reports for the cached values,
+	// split across shards the same way as MaxEntries, evicting a shard's
+	// least-recently-used entries on Set once its share is exceeded. The
+	// entry just set is never evicted, so a single value larger than a
+	// shard's share is still kept. 0 means unbounded. Only consumed if Sizer
+	// is also set.
+	MaxBytes int
+
+	// Sizer reports the size, in bytes, of a value passed to Set. Required
+	// for MaxBytes to have any effect.
+	Sizer func(interface{}) int
+
+	// Backend selects which Cacher implementation a service should construct.
+	// Supported values are BackendMemory (the default, used by NewCache) and
+	// BackendRedis, implemented by the sibling `redis` package, which lets
+	// multiple replicas of a service share one cache instead of each polling
+	// its upstream independently. For a memcache-backed deployment, use
+	// Store/MemcacheOptions with the `store/memcache` subpackage instead -
+	// there is no standalone memcache Cacher.
+	Backend string
+
+	// RedisOptions configures the redis-backed Cacher. It is only consumed
+	// when Backend is BackendRedis.
+	RedisOptions *RedisOptions
+
+	// MemcacheOptions configures the `store/memcache` Store implementation,
+	// for use with Config.Store.
+	MemcacheOptions *MemcacheOptions
+
+	// Metrics, if set, is notified of hits, misses, update durations/errors
+	// and entry counts. The `metrics` subpackage provides a Prometheus-backed
+	// implementation.
+	Metrics MetricsRecorder
+
+	// DefaultTTL is used by Set when no TTL is given explicitly via
+	// SetWithTTL. 0 means entries set via Set never expire.
+	DefaultTTL time.Duration
+
+	// RefreshAhead, if set, changes StartUpdates/UpdateContent to only
+	// refresh a key once it is within RefreshAhead of its TTL elapsing,
+	// rather than refreshing every registered key on every tick. Keys with
+	// no per-key TTL (see AddUpdateFuncWithTTL) are always refreshed, since
+	// there is no expiry to be "ahead of". 0 preserves the original
+	// behaviour of refreshing everything every tick.
+	RefreshAhead time.Duration
+
+	// ReturnLastGood, if true, keeps a key's last successfully loaded value
+	// in place when its update function errors, recording the error against
+	// the entry (see GetWithMeta) instead of leaving stale data to silently
+	// expire. If false, a failed update removes the key, so callers never
+	// see data known to be out of date.
+	ReturnLastGood bool
+
+	// ServeExpired, if true, lets Get and GetOrLoad continue to serve a
+	// value for up to MaxStale past its TTL instead of treating it as a
+	// miss, so a slow or failing upstream doesn't cause a cache stampede.
+	// MaxStale must also be set; with it unset or <= 0, ServeExpired has no
+	// effect and expired entries are evicted as normal.
+	ServeExpired bool
+
+	// MaxStale bounds how long ServeExpired will keep serving a value past
+	// its TTL before it is finally evicted.
+	MaxStale time.Duration
+
+	// Store, if set, is written through to on every SetWithTTL - and so on
+	// every Set, GetOrLoad/UpdateContent refresh and GetTyped fetch - in
+	// addition to the local sharded LRU, so other replicas sharing the same
+	// Store see the update. A local Get still always checks the in-process
+	// cache first and only consults Store on a local miss (see Delete for
+	// the same behaviour on removal). The `store/memory`, `store/redis` and
+	// `store/memcache` subpackages provide implementations.
+	Store Store
+
+	// Codec encodes/decodes values crossing the Store boundary. Defaults to
+	// JSONCodec if Store is set and Codec is nil.
+	Codec Codec
+}
+
+// RedisOptions configures the redis-backed Cacher implemented in the `redis`
+// subpackage.
+type RedisOptions struct {
+	Addr     string
+	Password string
+	DB       int
+	Prefix   string
+	TTL      time.Duration
+}
+
+// MemcacheOptions configures the memcache-backed Store implemented in the
+// `store/memcache` subpackage.
+type MemcacheOptions struct {
+	Addrs  []string
+	Prefix string
+	TTL    time.Duration
 }
 
 // NewCache create a cache object which will update at every updateInterval
@@ -43,60 +255,678 @@ func NewCache(ctx context.Context, config Config) (*Cache, error) {
 		}
 	}
 
-	return &Cache{
-		data:        sync.Map{},
-		config:      config,
-		close:       make(chan struct{}),
-		UpdateFuncs: make(map[string]func() (interface{}, error)),
-	}, nil
+	c := &Cache{
+		config:       config,
+		close:        make(chan struct{}),
+		janitorClose: make(chan struct{}),
+		UpdateFuncs:  make(map[string]func(ctx context.Context) (interface{}, error)),
+		updateTTLs:   make(map[string]time.Duration),
+		lastUpdated:  make(map[string]time.Time),
+		refreshAhead: make(map[string]time.Duration),
+		types:        make(map[string]*cacheType),
+	}
+	for i := range c.shards {
+		c.shards[i] = newShard()
+	}
+
+	return c, nil
+}
+
+// perShardLimit divides a cache-wide maximum across numShards, keeping it at
+// least 1 per shard so a small MaxEntries/MaxBytes doesn't round down to "no
+// limit". 0 is passed through unchanged, meaning unbounded.
+func perShardLimit(max int) int {
+	if max <= 0 {
+		return 0
+	}
+	if n := max / numShards; n > 0 {
+		return n
+	}
+	return 1
 }
 
-// Get retrieves the specific value for the specified key stored in `data` within the `Cache`
+// Get retrieves the specific value for the specified key, promoting it to
+// most-recently-used. An entry that has passed its TTL is treated as a miss
+// and is lazily deleted, unless Config.ServeExpired keeps it around as
+// stale - see GetWithMeta to also learn that an entry came back stale.
 func (dc *Cache) Get(key string) (interface{}, bool) {
-	return dc.data.Load(key)
+	value, _, ok := dc.GetWithMeta(key)
+	return value, ok
+}
+
+// GetWithMeta is like Get, but also returns Meta describing the entry: when
+// it was last successfully updated, the error from its most recent failed
+// update (if Config.ReturnLastGood kept it in place), and whether it is
+// being served stale, either past its TTL under Config.ServeExpired or
+// following a failed refresh.
+func (dc *Cache) GetWithMeta(key string) (interface{}, Meta, bool) {
+	s := dc.shards[shardIndex(key)]
+	value, found, expired, stale := s.get(key, time.Now(), dc.maxStale(), dc.wrapOnEvict(EvictedExpired))
+
+	if expired && !stale {
+		atomic.AddInt64(&dc.stats.Evictions, 1)
+	}
+	if !found {
+		if value, ok := dc.readThrough(key); ok {
+			atomic.AddInt64(&dc.stats.Hits, 1)
+			if dc.config.Metrics != nil {
+				dc.config.Metrics.Hit(key)
+			}
+			return value, Meta{LastUpdated: time.Now()}, true
+		}
+
+		atomic.AddInt64(&dc.stats.Misses, 1)
+		if dc.config.Metrics != nil {
+			dc.config.Metrics.Miss(key)
+		}
+		return nil, Meta{}, false
+	}
+
+	atomic.AddInt64(&dc.stats.Hits, 1)
+	if dc.config.Metrics != nil {
+		dc.config.Metrics.Hit(key)
+	}
+
+	meta := Meta{
+		LastUpdated: value.updatedAt,
+		LastError:   value.lastErr,
+		Stale:       stale || value.lastErr != nil,
+	}
+	return value.value, meta, true
+}
+
+// maxStale returns the duration Get/GetWithMeta should keep serving an
+// expired entry for, or 0 if Config.ServeExpired is off.
+func (dc *Cache) maxStale() time.Duration {
+	if !dc.config.ServeExpired {
+		return 0
+	}
+	return dc.config.MaxStale
+}
+
+// codec returns Config.Codec, or JSONCodec if none was given.
+func (dc *Cache) codec() Codec {
+	if dc.config.Codec != nil {
+		return dc.config.Codec
+	}
+	return JSONCodec{}
 }
 
-// Set adds the specified value with the specified key in `data` within the `Cache`
+// readThrough consults Config.Store for key on a local miss, repopulating
+// the local cache so repeated reads of the same key don't keep round-
+// tripping to Store. Store has no notion of this cache's per-key TTLs, so
+// the repopulated entry uses Config.DefaultTTL, the same as a plain Set.
+func (dc *Cache) readThrough(key string) (interface{}, bool) {
+	if dc.config.Store == nil {
+		return nil, false
+	}
+
+	raw, ok := dc.config.Store.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	value, err := dc.codec().Decode(raw)
+	if err != nil {
+		log.Error(context.Background(), "failed to decode value read through from cache store", err, log.Data{"key": key})
+		return nil, false
+	}
+
+	dc.SetWithTTL(key, value, dc.config.DefaultTTL)
+	return value, true
+}
+
+// Set adds the specified value with the specified key within the `Cache`,
+// expiring it after Config.DefaultTTL (0 means it never expires, matching
+// the cache's original behaviour).
 func (dc *Cache) Set(key string, data interface{}) {
-	dc.data.Store(key, data)
+	dc.SetWithTTL(key, data, dc.config.DefaultTTL)
+}
+
+// SetWithTTL is like Set but the value is treated as expired, and removed on
+// the next Get or janitor sweep, once ttl has elapsed. A ttl of 0 means the
+// value never expires, the same as Set. If Config.MaxEntries or
+// Config.MaxBytes is set, inserting may evict the least-recently-used entry
+// in the same shard to make room.
+func (dc *Cache) SetWithTTL(key string, data interface{}, ttl time.Duration) {
+	now := time.Now()
+	e := entry{value: data, updatedAt: now}
+	if ttl != 0 {
+		e.expiresAt = now.Add(ttl)
+	}
+
+	size := 0
+	if dc.config.Sizer != nil {
+		size = dc.config.Sizer(data)
+	}
+
+	s := dc.shards[shardIndex(key)]
+	evicted := s.set(key, e, size, perShardLimit(dc.config.MaxEntries), perShardLimit(dc.config.MaxBytes), dc.wrapOnEvict(EvictedCapacity))
+	if evicted > 0 {
+		atomic.AddInt64(&dc.stats.Evictions, int64(evicted))
+	}
+
+	if dc.config.Metrics != nil {
+		dc.config.Metrics.Entries(dc.totalEntries())
+	}
+
+	if dc.config.Store != nil {
+		encoded, err := dc.codec().Encode(data)
+		if err != nil {
+			log.Error(context.Background(), "failed to encode value for cache store", err, log.Data{"key": key})
+		} else if err := dc.config.Store.Set(key, encoded, ttl); err != nil {
+			log.Error(context.Background(), "failed to write value through to cache store", err, log.Data{"key": key})
+		}
+	}
+}
+
+// Delete removes key from the cache, invoking any OnEviction callback with
+// EvictedManual, and from Config.Store if one is set.
+func (dc *Cache) Delete(key string) {
+	s := dc.shards[shardIndex(key)]
+	value, found := s.delete(key)
+
+	if dc.config.Store != nil {
+		if err := dc.config.Store.Delete(key); err != nil {
+			log.Error(context.Background(), "failed to delete value from cache store", err, log.Data{"key": key})
+		}
+	}
+
+	if !found {
+		return
+	}
+
+	atomic.AddInt64(&dc.stats.Evictions, 1)
+	if dc.config.Metrics != nil {
+		dc.config.Metrics.Entries(dc.totalEntries())
+	}
+	if dc.onEvict != nil {
+		dc.onEvict(key, value.value, EvictedManual)
+	}
+}
+
+// OnEviction registers a callback invoked whenever an entry is removed from
+// the cache, whether through TTL expiry, LRU capacity eviction or a manual
+// Delete. Only one callback can be registered at a time; calling OnEviction
+// again replaces it.
+//
+// EvictedExpired only fires proactively if Config.CleanupInterval is set -
+// see its doc comment. Without it, an expired key is only noticed (and this
+// callback only fires for it) the next time it's read.
+func (dc *Cache) OnEviction(fn func(key string, value interface{}, reason EvictionReason)) {
+	dc.onEvict = fn
+}
+
+// OnUpdateResult registers a callback invoked after every update function
+// call made by UpdateContent or GetOrLoad, whether it succeeded or failed.
+// stale reports whether the key's existing value is now being kept around
+// despite the failure, under Config.ReturnLastGood. Only one callback can be
+// registered at a time; calling OnUpdateResult again replaces it.
+func (dc *Cache) OnUpdateResult(fn func(key string, err error, stale bool)) {
+	dc.onUpdateResult = fn
+}
+
+// wrapOnEvict adapts dc.onEvict, if set, to the shard package's internal
+// entry type and a fixed reason, or returns nil so shard methods can skip
+// the per-entry callback entirely when nothing is registered.
+func (dc *Cache) wrapOnEvict(reason EvictionReason) func(key string, value entry) {
+	if dc.onEvict == nil {
+		return nil
+	}
+	return func(key string, value entry) {
+		dc.onEvict(key, value.value, reason)
+	}
+}
+
+// totalEntries sums the number of entries held across all shards.
+func (dc *Cache) totalEntries() int {
+	total := 0
+	for _, s := range dc.shards {
+		total += s.len()
+	}
+	return total
+}
+
+// Stats returns a snapshot of the cache's hit/miss/eviction counters.
+func (dc *Cache) Stats() Stats {
+	return Stats{
+		Hits:      atomic.LoadInt64(&dc.stats.Hits),
+		Misses:    atomic.LoadInt64(&dc.stats.Misses),
+		Evictions: atomic.LoadInt64(&dc.stats.Evictions),
+	}
 }
 
 // Close closes the caching of data when called where the data will no longer be updated and the data itself is reset
 func (dc *Cache) Close() {
-	if dc.config.UpdateInterval != nil {
+	dc.mu.Lock()
+	janitorStarted := dc.janitorStarted
+	updateLoopStarted := dc.updateLoopStarted
+	dc.mu.Unlock()
+
+	if janitorStarted {
+		dc.janitorClose <- struct{}{}
+	}
+
+	if updateLoopStarted {
 		dc.close <- struct{}{}
+
+		dc.mu.Lock()
+		keys := make([]string, 0, len(dc.UpdateFuncs))
 		for key := range dc.UpdateFuncs {
-			dc.data.Store(key, "")
+			keys = append(keys, key)
+		}
+		dc.UpdateFuncs = make(map[string]func(ctx context.Context) (interface{}, error))
+		dc.updateTTLs = make(map[string]time.Duration)
+		dc.mu.Unlock()
+
+		// Reset each key locally only - Set/SetWithTTL writes through to
+		// Config.Store, and a replica shutting down must not overwrite the
+		// entries other, still-live replicas are reading through the shared
+		// Store.
+		for _, key := range keys {
+			dc.shards[shardIndex(key)].delete(key)
+		}
+	}
+
+	if dc.config.Store != nil {
+		if err := dc.config.Store.Close(); err != nil {
+			log.Error(context.Background(), "failed to close cache store", err)
 		}
-		dc.UpdateFuncs = make(map[string]func() (interface{}, error))
 	}
 }
 
 // AddUpdateFunc adds an update function to the cache for a specific data corresponding to the `key` passed to the function
 // This update function will then be triggered once or at every fixed interval as per the prior setup of the TopicCache
+//
+// Deprecated: use AddUpdateFuncCtx so a shutting-down service or a cancelled
+// request can abort the underlying fetch. AddUpdateFunc wraps updateFunc in
+// a version that ignores the context passed to it.
 func (dc *Cache) AddUpdateFunc(key string, updateFunc func() (interface{}, error)) {
+	dc.AddUpdateFuncCtx(key, func(context.Context) (interface{}, error) {
+		return updateFunc()
+	})
+}
+
+// AddUpdateFuncCtx is like AddUpdateFunc, but updateFunc receives the
+// context passed to UpdateContent/StartUpdates/GetOrLoad, so it can abort an
+// in-flight upstream fetch when that context is cancelled.
+func (dc *Cache) AddUpdateFuncCtx(key string, updateFunc func(ctx context.Context) (interface{}, error)) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.UpdateFuncs[key] = updateFunc
+}
+
+// AddUpdateFuncWithTTL is like AddUpdateFunc, but every value it produces is
+// stored with the given per-key TTL instead of being kept forever.
+//
+// Deprecated: use AddUpdateFuncWithTTLCtx for the same reason AddUpdateFuncCtx
+// replaces AddUpdateFunc.
+func (dc *Cache) AddUpdateFuncWithTTL(key string, updateFunc func() (interface{}, error), ttl time.Duration) {
+	dc.AddUpdateFuncWithTTLCtx(key, func(context.Context) (interface{}, error) {
+		return updateFunc()
+	}, ttl)
+}
+
+// AddUpdateFuncWithTTLCtx is AddUpdateFuncCtx with a per-key TTL, the
+// context-aware counterpart to AddUpdateFuncWithTTL.
+func (dc *Cache) AddUpdateFuncWithTTLCtx(key string, updateFunc func(ctx context.Context) (interface{}, error), ttl time.Duration) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
 	dc.UpdateFuncs[key] = updateFunc
+	dc.updateTTLs[key] = ttl
+}
+
+// GetOrLoad returns the value for key, refreshing it via its registered
+// update function on a miss or expired entry. Concurrent callers missing on
+// the same key collapse into a single call to the update function, whether
+// they arrived via GetOrLoad or the periodic refresh in UpdateContent.
+func (dc *Cache) GetOrLoad(ctx context.Context, key string) (interface{}, error) {
+	if value, ok := dc.Get(key); ok {
+		return value, nil
+	}
+
+	value, err := dc.loadAndSet(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load value for key %s. error: %v", key, err)
+	}
+
+	return value, nil
+}
+
+// TypeOptions configures a cache type registered with Cache.RegisterType.
+type TypeOptions struct {
+	// TTL is the per-entry TTL applied to every key derived from this type.
+	TTL time.Duration
+
+	// RefreshAhead overrides Config.RefreshAhead for this type's keys alone.
+	// 0 falls back to Config.RefreshAhead.
+	RefreshAhead time.Duration
+
+	// MaxEntries bounds how many distinct keys this type may hold at once.
+	// Once exceeded, the type's least-recently-registered key is evicted.
+	// 0 means this type is only bounded by whatever Config.MaxEntries
+	// enforces cache-wide.
+	MaxEntries int
+
+	// Key derives the part of the cache key specific to req. If nil, a
+	// reflect-based default formats req with fmt.Sprintf("%#v", req).
+	Key func(req interface{}) string
+}
+
+// cacheType is a registered fetcher shared by every key requested through
+// GetTyped for that type name, along the lines of the Consul agent cache's
+// "cache types". keys records the keys GetTyped has derived for this type,
+// oldest first, so MaxEntries can evict the least recently registered one.
+// keys is only ever read or written while Cache.mu is held.
+type cacheType struct {
+	name    string
+	fetcher func(ctx context.Context, req interface{}) (interface{}, error)
+	opts    TypeOptions
+	keys    []string
 }
 
-// UpdateContent calls all the update functions with a key value stored in the Cache to update the relevant data with the same key values
-func (dc *Cache) UpdateContent(_ context.Context) error {
-	for key, updateFunc := range dc.UpdateFuncs {
-		updatedContent, err := updateFunc()
+func (t *cacheType) keyFor(req interface{}) string {
+	if t.opts.Key != nil {
+		return t.name + ":" + t.opts.Key(req)
+	}
+	return fmt.Sprintf("%s:%#v", t.name, req)
+}
+
+// RegisterType registers a fetcher for a named cache type, so a single
+// fetcher can populate many parameterised entries (e.g. dataset-by-id,
+// topic-by-slug) instead of one UpdateFunc per logical key. Call GetTyped to
+// read/refresh an entry for a particular req.
+func (dc *Cache) RegisterType(name string, fetcher func(ctx context.Context, req interface{}) (interface{}, error), opts TypeOptions) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.types[name] = &cacheType{name: name, fetcher: fetcher, opts: opts}
+}
+
+// GetTyped returns the value for req under the cache type registered as
+// typeName, loading it through that type's fetcher on a miss or expired
+// entry. req is hashed into a cache key via TypeOptions.Key (or a
+// reflect-based default), so the background refresh loop in UpdateContent
+// only ever refreshes keys that have been requested through GetTyped at
+// least once.
+func (dc *Cache) GetTyped(ctx context.Context, typeName string, req interface{}) (interface{}, error) {
+	dc.mu.Lock()
+	t, ok := dc.types[typeName]
+	if !ok {
+		dc.mu.Unlock()
+		return nil, fmt.Errorf("no cache type registered with name %s", typeName)
+	}
+
+	key := t.keyFor(req)
+	var oldest string
+	var evictOldest bool
+
+	if _, registered := dc.UpdateFuncs[key]; !registered {
+		// Inlined rather than calling AddUpdateFuncWithTTLCtx: dc.mu is
+		// already held here and isn't reentrant.
+		dc.UpdateFuncs[key] = func(ctx context.Context) (interface{}, error) {
+			return t.fetcher(ctx, req)
+		}
+		dc.updateTTLs[key] = t.opts.TTL
+
+		if t.opts.RefreshAhead > 0 {
+			dc.refreshAhead[key] = t.opts.RefreshAhead
+		}
+
+		t.keys = append(t.keys, key)
+		if t.opts.MaxEntries > 0 && len(t.keys) > t.opts.MaxEntries {
+			oldest = t.keys[0]
+			t.keys = t.keys[1:]
+			evictOldest = true
+			delete(dc.UpdateFuncs, oldest)
+			delete(dc.updateTTLs, oldest)
+			delete(dc.lastUpdated, oldest)
+			delete(dc.refreshAhead, oldest)
+		}
+	}
+	dc.mu.Unlock()
+
+	// Delete is called outside the lock above: it invokes OnEviction, and
+	// that callback shouldn't run while dc.mu is held.
+	if evictOldest {
+		dc.Delete(oldest)
+	}
+
+	return dc.GetOrLoad(ctx, key)
+}
+
+// loadAndSet runs key's registered update function and stores its result,
+// collapsing concurrent callers for the same key - whether they came from
+// GetOrLoad or a periodic refresh in UpdateContent - into a single call via
+// loadGroup. On failure, the key's existing value is kept in place and
+// annotated with the error when Config.ReturnLastGood is set; otherwise the
+// key is removed, since its cached value can no longer be trusted.
+func (dc *Cache) loadAndSet(ctx context.Context, key string) (interface{}, error) {
+	dc.mu.Lock()
+	updateFunc, ok := dc.UpdateFuncs[key]
+	dc.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no update function registered for key %s", key)
+	}
+
+	value, err, _ := dc.loadGroup.Do(key, func() (interface{}, error) {
+		updatedContent, err := updateFunc(ctx)
 		if err != nil {
-			return fmt.Errorf("failed to update search cache for %s. error: %v", key, err)
+			return nil, err
 		}
-		dc.Set(key, updatedContent)
+
+		dc.mu.Lock()
+		ttl := dc.updateTTLs[key]
+		dc.mu.Unlock()
+
+		dc.SetWithTTL(key, updatedContent, ttl)
+
+		dc.mu.Lock()
+		dc.lastUpdated[key] = time.Now()
+		dc.mu.Unlock()
+
+		return updatedContent, nil
+	})
+
+	if err != nil {
+		stale := false
+		if dc.config.ReturnLastGood {
+			stale = dc.shards[shardIndex(key)].recordError(key, err)
+		} else {
+			dc.Delete(key)
+		}
+		if dc.onUpdateResult != nil {
+			dc.onUpdateResult(key, err, stale)
+		}
+		return nil, err
+	}
+
+	if dc.onUpdateResult != nil {
+		dc.onUpdateResult(key, nil, false)
+	}
+	return value, nil
+}
+
+// dueForRefresh reports whether key should be refreshed in this update
+// cycle. With Config.RefreshAhead unset, every key is always due, matching
+// the cache's original behaviour. Otherwise a key with no per-key TTL is
+// still always due, since it has no expiry to be "ahead of"; a key with a
+// TTL is only due once it is within RefreshAhead of expiring. A key derived
+// from a registered type with its own TypeOptions.RefreshAhead (see
+// RegisterType) uses that instead of Config.RefreshAhead.
+func (dc *Cache) dueForRefresh(key string, now time.Time) bool {
+	dc.mu.Lock()
+	refreshAhead, overridden := dc.refreshAhead[key]
+	ttl := dc.updateTTLs[key]
+	last, hasLast := dc.lastUpdated[key]
+	dc.mu.Unlock()
+
+	if !overridden {
+		refreshAhead = dc.config.RefreshAhead
+	}
+	if refreshAhead <= 0 {
+		return true
+	}
+
+	if ttl <= 0 {
+		return true
+	}
+
+	if !hasLast {
+		return true
+	}
+
+	return now.Sub(last) >= ttl-refreshAhead
+}
+
+// UpdateContent calls all the update functions with a key value stored in the Cache to update the relevant data with the same key values.
+// Update functions run concurrently, bounded by Config.UpdateConcurrency, and a failing update function no longer
+// aborts the cycle: every other key still gets its chance to refresh before UpdateContent returns an error.
+func (dc *Cache) UpdateContent(ctx context.Context) error {
+	dc.mu.Lock()
+	keys := make([]string, 0, len(dc.UpdateFuncs))
+	for key := range dc.UpdateFuncs {
+		keys = append(keys, key)
+	}
+	registered := len(keys)
+	dc.mu.Unlock()
+
+	if registered == 0 {
+		return nil
+	}
+
+	start := time.Now()
+
+	due := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if dc.dueForRefresh(key, start) {
+			due = append(due, key)
+		}
+	}
+
+	if len(due) == 0 {
+		return nil
+	}
+
+	concurrency := dc.config.UpdateConcurrency
+	if concurrency <= 0 || concurrency > len(due) {
+		concurrency = len(due)
+	}
+
+	jobs := make(chan string, len(due))
+	for _, key := range due {
+		jobs <- key
+	}
+	close(jobs)
+
+	var mu sync.Mutex
+	var errs []string
+	var succeeded int
+
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for key := range jobs {
+				keyStart := time.Now()
+				_, err := dc.loadAndSet(ctx, key)
+				if err != nil {
+					if dc.config.Metrics != nil {
+						dc.config.Metrics.UpdateError(key)
+					}
+					mu.Lock()
+					errs = append(errs, fmt.Sprintf("failed to update search cache for %s. error: %v", key, err))
+					mu.Unlock()
+					continue
+				}
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+				if dc.config.Metrics != nil {
+					dc.config.Metrics.UpdateDuration(key, time.Since(keyStart))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	log.Info(ctx, "cache update cycle completed", log.Data{
+		"keys_registered": registered,
+		"keys_due":        len(due),
+		"keys_succeeded":  succeeded,
+		"keys_failed":     len(errs),
+		"duration":        time.Since(start).String(),
+	})
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errors.New(errs[0])
+	default:
+		return fmt.Errorf("failed to update cache: %s", strings.Join(errs, "; "))
+	}
+}
+
+// startJanitor, if Config.CleanupInterval is set, starts a goroutine that
+// periodically sweeps every shard for expired entries so memory doesn't grow
+// unbounded when a TTL'd key is never read again. This sweep is also the
+// only proactive eviction this cache does - without CleanupInterval, expired
+// entries (and the OnEviction(EvictedExpired) callback for them) only
+// surface lazily, on the next Get/GetWithMeta for that key.
+func (dc *Cache) startJanitor(ctx context.Context) {
+	if dc.config.CleanupInterval == nil {
+		return
 	}
-	return nil
+
+	dc.mu.Lock()
+	dc.janitorStarted = true
+	dc.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(*dc.config.CleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				now := time.Now()
+				var evicted int64
+				for _, s := range dc.shards {
+					evicted += int64(s.removeExpired(now, dc.maxStale(), dc.wrapOnEvict(EvictedExpired)))
+				}
+				if evicted > 0 {
+					atomic.AddInt64(&dc.stats.Evictions, evicted)
+					if dc.config.Metrics != nil {
+						dc.config.Metrics.Entries(dc.totalEntries())
+					}
+				}
+			case <-dc.janitorClose:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
 }
 
 // StartUpdates informs the cache to start updating the cache data at every update interval which was configured when setting up the cache
 func (dc *Cache) StartUpdates(ctx context.Context, errorChannel chan error) {
-	if len(dc.UpdateFuncs) == 0 {
+	dc.startJanitor(ctx)
+
+	dc.mu.Lock()
+	hasUpdateFuncs := len(dc.UpdateFuncs) > 0
+	dc.mu.Unlock()
+
+	if !hasUpdateFuncs {
 		return
 	}
 
 	if dc.config.UpdateInterval != nil {
+		dc.mu.Lock()
+		dc.updateLoopStarted = true
+		dc.mu.Unlock()
+
 		// Create a new goroutine to handle periodic updates with the specified interval
 		go func() {
 			ticker := time.NewTicker(*dc.config.UpdateInterval)
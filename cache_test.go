@@ -3,7 +3,9 @@ package cache
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -12,20 +14,35 @@ import (
 
 const test = "test"
 
+// entryPresent reports whether key is still physically held by its shard,
+// bypassing the lazy-expiry check in Get.
+func (dc *Cache) entryPresent(key string) bool {
+	s := dc.shards[shardIndex(key)]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.items[key]
+	return ok
+}
+
 func getTestCache(config Config) *Cache {
 	testCache := Cache{
-		data:        sync.Map{},
-		config:      config,
-		close:       make(chan struct{}),
-		UpdateFuncs: make(map[string]func() (interface{}, error)),
+		config:       config,
+		close:        make(chan struct{}),
+		janitorClose: make(chan struct{}),
+		UpdateFuncs:  make(map[string]func(ctx context.Context) (interface{}, error)),
+		updateTTLs:   make(map[string]time.Duration),
+		lastUpdated:  make(map[string]time.Time),
+	}
+	for i := range testCache.shards {
+		testCache.shards[i] = newShard()
 	}
 
-	testCache.data.Store("string", test)
-	testCache.data.Store("int", 1)
-	testCache.data.Store("bool", false)
-	testCache.data.Store("float", 1.1)
+	testCache.Set("string", test)
+	testCache.Set("int", 1)
+	testCache.Set("bool", false)
+	testCache.Set("float", 1.1)
 
-	testCache.UpdateFuncs["string"] = func() (interface{}, error) {
+	testCache.UpdateFuncs["string"] = func(context.Context) (interface{}, error) {
 		val, ok := testCache.Get("string")
 
 		// the first update
@@ -36,21 +53,21 @@ func getTestCache(config Config) *Cache {
 		// the second update or more
 		return "test3", nil
 	}
-	testCache.UpdateFuncs["int"] = func() (interface{}, error) {
+	testCache.UpdateFuncs["int"] = func(context.Context) (interface{}, error) {
 		val, ok := testCache.Get("int")
 		if ok && val == 1 {
 			return 2, nil
 		}
 		return 3, nil
 	}
-	testCache.UpdateFuncs["bool"] = func() (interface{}, error) {
+	testCache.UpdateFuncs["bool"] = func(context.Context) (interface{}, error) {
 		val, ok := testCache.Get("bool")
 		if ok && val == false {
 			return true, nil
 		}
 		return false, nil
 	}
-	testCache.UpdateFuncs["float"] = func() (interface{}, error) {
+	testCache.UpdateFuncs["float"] = func(context.Context) (interface{}, error) {
 		val, ok := testCache.Get("float")
 		if ok && val == 1.1 {
 			return 2.2, nil
@@ -137,27 +154,23 @@ func TestClose(t *testing.T) {
 
 		testCache := getTestCache(config)
 
-		go testCache.StartUpdates(ctx, errorChan)
+		testCache.StartUpdates(ctx, errorChan)
 
 		Convey("When Close is called", func() {
 			testCache.Close()
 
-			Convey("Then all the values of the cache data should be emptied", func() {
-				cacheStringValue, ok := testCache.Get("string")
-				So(cacheStringValue, ShouldBeEmpty)
-				So(ok, ShouldBeTrue)
+			Convey("Then all the values of the cache data should be removed locally", func() {
+				_, ok := testCache.Get("string")
+				So(ok, ShouldBeFalse)
 
-				cacheIntValue, ok := testCache.Get("int")
-				So(cacheIntValue, ShouldBeEmpty)
-				So(ok, ShouldBeTrue)
+				_, ok = testCache.Get("int")
+				So(ok, ShouldBeFalse)
 
-				cacheBoolValue, ok := testCache.Get("bool")
-				So(cacheBoolValue, ShouldBeEmpty)
-				So(ok, ShouldBeTrue)
+				_, ok = testCache.Get("bool")
+				So(ok, ShouldBeFalse)
 
-				cacheFloatValue, ok := testCache.Get("float")
-				So(cacheFloatValue, ShouldBeEmpty)
-				So(ok, ShouldBeTrue)
+				_, ok = testCache.Get("float")
+				So(ok, ShouldBeFalse)
 
 				Convey("And update functions in cache should be emptied", func() {
 					So(testCache.UpdateFuncs, ShouldBeEmpty)
@@ -173,7 +186,7 @@ func TestClose(t *testing.T) {
 
 		testCache := getTestCache(config)
 
-		go testCache.StartUpdates(ctx, errorChan)
+		testCache.StartUpdates(ctx, errorChan)
 
 		Convey("When Close is called", func() {
 			testCache.Close()
@@ -181,6 +194,47 @@ func TestClose(t *testing.T) {
 			Convey("Then this function does nothing to the cache as StartUpdates go-routine was stopped beforehand", func() {})
 		})
 	})
+
+	Convey("Given a cache that StartUpdates was never called on", t, func() {
+		testCache := getTestCache(Config{})
+
+		Convey("When Close is called", func() {
+			done := make(chan struct{})
+			go func() {
+				testCache.Close()
+				close(done)
+			}()
+
+			Convey("Then it returns without blocking on an update loop that was never started", func() {
+				select {
+				case <-done:
+				case <-time.After(time.Second):
+					t.Fatal("Close blocked sending to close with no update loop goroutine running")
+				}
+			})
+		})
+	})
+
+	Convey("Given a cache with a CleanupInterval configured but StartUpdates never called", t, func() {
+		cleanupInterval := time.Hour
+		testCache := getTestCache(Config{CleanupInterval: &cleanupInterval})
+
+		Convey("When Close is called", func() {
+			done := make(chan struct{})
+			go func() {
+				testCache.Close()
+				close(done)
+			}()
+
+			Convey("Then it returns without blocking on a janitor that was never started", func() {
+				select {
+				case <-done:
+				case <-time.After(time.Second):
+					t.Fatal("Close blocked sending to janitorClose with no janitor goroutine running")
+				}
+			})
+		})
+	})
 }
 
 func TestAddUpdateFunc(t *testing.T) {
@@ -199,9 +253,12 @@ func TestAddUpdateFunc(t *testing.T) {
 		Convey("When AddUpdateFunc is called", func() {
 			testCache.AddUpdateFunc("test", updateFunc)
 
-			Convey("Then the update function is added to the cache", func() {
+			Convey("Then the update function is added to the cache, wrapped to ignore its context", func() {
 				So(testCache.UpdateFuncs["test"], ShouldNotBeEmpty)
-				So(testCache.UpdateFuncs["test"], ShouldEqual, updateFunc)
+
+				value, err := testCache.UpdateFuncs["test"](context.Background())
+				So(err, ShouldBeNil)
+				So(value, ShouldEqual, "test")
 			})
 		})
 	})
@@ -255,8 +312,8 @@ func TestUpdateContent(t *testing.T) {
 
 		testCache := getTestCache(config)
 
-		testCache.UpdateFuncs = make(map[string]func() (interface{}, error))
-		testCache.UpdateFuncs["error_update_func"] = func() (interface{}, error) {
+		testCache.UpdateFuncs = make(map[string]func(ctx context.Context) (interface{}, error))
+		testCache.UpdateFuncs["error_update_func"] = func(context.Context) (interface{}, error) {
 			return nil, errors.New("unexpected error")
 		}
 
@@ -335,9 +392,8 @@ func TestStartUpdates(t *testing.T) {
 					// Give some time to ensure no more updates occur
 					time.Sleep(updateCacheInterval + 10*time.Millisecond)
 
-					cacheStringValue, ok = testCache.Get("string")
-					So(cacheStringValue, ShouldEqual, "") // No further updates expected
-					So(ok, ShouldBeTrue)
+					_, ok = testCache.Get("string")
+					So(ok, ShouldBeFalse) // removed locally, no further updates expected
 				})
 			})
 		})
@@ -350,7 +406,7 @@ func TestStartUpdates(t *testing.T) {
 		}
 
 		testCache := getTestCache(config)
-		testCache.UpdateFuncs = make(map[string]func() (interface{}, error)) // No update functions
+		testCache.UpdateFuncs = make(map[string]func(ctx context.Context) (interface{}, error)) // No update functions
 
 		Convey("When StartUpdates is called", func() {
 			testCache.StartUpdates(ctx, errorChan)
@@ -503,7 +559,7 @@ func TestStartAndManageUpdates(t *testing.T) {
 
 		testCache := getTestCache(config)
 
-		testCache.UpdateFuncs = make(map[string]func() (interface{}, error), 0)
+		testCache.UpdateFuncs = make(map[string]func(ctx context.Context) (interface{}, error), 0)
 
 		Convey("When StartUpdates is called", func() {
 			testCache.StartAndManageUpdates(ctx, errorChan)
@@ -558,3 +614,1068 @@ func TestStartAndManageUpdates(t *testing.T) {
 		})
 	})
 }
+
+func TestSetWithTTL(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a cache", t, func() {
+		testCache := getTestCache(Config{})
+
+		Convey("When SetWithTTL is called with a TTL that has not yet elapsed", func() {
+			testCache.SetWithTTL("ttl-key", "value", time.Hour)
+
+			Convey("Then the value should still be retrievable", func() {
+				value, ok := testCache.Get("ttl-key")
+				So(ok, ShouldBeTrue)
+				So(value, ShouldEqual, "value")
+			})
+		})
+
+		Convey("When SetWithTTL is called with a TTL that has already elapsed", func() {
+			testCache.SetWithTTL("ttl-key", "value", -time.Second)
+
+			Convey("Then Get should report a miss and lazily delete the entry", func() {
+				value, ok := testCache.Get("ttl-key")
+				So(ok, ShouldBeFalse)
+				So(value, ShouldBeNil)
+				So(testCache.entryPresent("ttl-key"), ShouldBeFalse)
+			})
+		})
+
+		Convey("When SetWithTTL is called with a zero TTL", func() {
+			testCache.SetWithTTL("ttl-key", "value", 0)
+
+			Convey("Then the value should never expire", func() {
+				value, ok := testCache.Get("ttl-key")
+				So(ok, ShouldBeTrue)
+				So(value, ShouldEqual, "value")
+			})
+		})
+	})
+}
+
+func TestAddUpdateFuncWithTTL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	Convey("Given an update function registered with a TTL", t, func() {
+		testCache := getTestCache(Config{})
+		testCache.UpdateFuncs = make(map[string]func(ctx context.Context) (interface{}, error))
+		testCache.updateTTLs = make(map[string]time.Duration)
+
+		testCache.AddUpdateFuncWithTTL("ttl-key", func() (interface{}, error) {
+			return "updated", nil
+		}, -time.Second)
+
+		Convey("When UpdateContent is called", func() {
+			err := testCache.UpdateContent(ctx)
+
+			Convey("Then the value is stored but expires immediately", func() {
+				So(err, ShouldBeNil)
+
+				_, ok := testCache.Get("ttl-key")
+				So(ok, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestJanitor(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errorChan := make(chan error, 1)
+
+	Convey("Given a cache with a cleanup interval and an expired entry", t, func() {
+		cleanupInterval := 10 * time.Millisecond
+		testCache := getTestCache(Config{CleanupInterval: &cleanupInterval})
+		testCache.UpdateFuncs = make(map[string]func(ctx context.Context) (interface{}, error))
+		testCache.SetWithTTL("ttl-key", "value", -time.Second)
+
+		Convey("When StartUpdates is called", func() {
+			go testCache.StartUpdates(ctx, errorChan)
+
+			Convey("Then the janitor should evict the expired entry", func() {
+				time.Sleep(cleanupInterval + 10*time.Millisecond)
+
+				So(testCache.entryPresent("ttl-key"), ShouldBeFalse)
+			})
+		})
+	})
+}
+
+// fakeMetrics is a minimal MetricsRecorder used to test that Entries is
+// kept up to date as entries are evicted, not just as they're set.
+type fakeMetrics struct {
+	entries int64
+}
+
+func (f *fakeMetrics) Hit(string)                           {}
+func (f *fakeMetrics) Miss(string)                          {}
+func (f *fakeMetrics) UpdateDuration(string, time.Duration) {}
+func (f *fakeMetrics) UpdateError(string)                   {}
+func (f *fakeMetrics) Entries(n int)                        { atomic.StoreInt64(&f.entries, int64(n)) }
+
+func TestMetricsEntries(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache with Metrics configured and a manually deleted key", t, func() {
+		metrics := &fakeMetrics{}
+		testCache, err := NewCache(ctx, Config{Metrics: metrics})
+		So(err, ShouldBeNil)
+
+		testCache.Set("key", "value")
+		So(atomic.LoadInt64(&metrics.entries), ShouldEqual, 1)
+
+		Convey("When Delete is called", func() {
+			testCache.Delete("key")
+
+			Convey("Then Entries reflects the removal", func() {
+				So(atomic.LoadInt64(&metrics.entries), ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("Given a cache with Metrics configured and an expired key", t, func() {
+		metrics := &fakeMetrics{}
+		cleanupInterval := 10 * time.Millisecond
+		testCache, err := NewCache(ctx, Config{Metrics: metrics, CleanupInterval: &cleanupInterval})
+		So(err, ShouldBeNil)
+
+		testCache.SetWithTTL("key", "value", -time.Second)
+
+		Convey("When the janitor sweeps it away", func() {
+			testCache.startJanitor(ctx)
+			time.Sleep(cleanupInterval + 10*time.Millisecond)
+
+			Convey("Then Entries reflects the removal", func() {
+				So(atomic.LoadInt64(&metrics.entries), ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestSetWithMaxEntries(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache bounded to a small number of entries", t, func() {
+		testCache, err := NewCache(ctx, Config{MaxEntries: numShards})
+		So(err, ShouldBeNil)
+
+		Convey("When more keys are set than the cache can hold", func() {
+			for i := 0; i < numShards*10; i++ {
+				testCache.Set(fmt.Sprintf("key-%d", i), i)
+			}
+
+			Convey("Then the least-recently-used entries should have been evicted", func() {
+				So(testCache.Stats().Evictions, ShouldBeGreaterThan, 0)
+
+				present := 0
+				for i := 0; i < numShards*10; i++ {
+					if _, ok := testCache.Get(fmt.Sprintf("key-%d", i)); ok {
+						present++
+					}
+				}
+				So(present, ShouldBeLessThanOrEqualTo, numShards)
+			})
+		})
+	})
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache", t, func() {
+		testCache, err := NewCache(ctx, Config{})
+		So(err, ShouldBeNil)
+
+		testCache.Set("key", "value")
+
+		Convey("When Get is called for a hit and a miss", func() {
+			_, _ = testCache.Get("key")
+			_, _ = testCache.Get("missing")
+
+			Convey("Then Stats should reflect both", func() {
+				stats := testCache.Stats()
+				So(stats.Hits, ShouldEqual, 1)
+				So(stats.Misses, ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestGetOrLoad(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache with a registered update function and no cached value", t, func() {
+		testCache, err := NewCache(ctx, Config{})
+		So(err, ShouldBeNil)
+
+		var calls int32
+		testCache.AddUpdateFunc("key", func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return "loaded", nil
+		})
+
+		Convey("When GetOrLoad is called", func() {
+			value, err := testCache.GetOrLoad(ctx, "key")
+
+			Convey("Then the update function is invoked and the value cached", func() {
+				So(err, ShouldBeNil)
+				So(value, ShouldEqual, "loaded")
+
+				cached, ok := testCache.Get("key")
+				So(ok, ShouldBeTrue)
+				So(cached, ShouldEqual, "loaded")
+			})
+		})
+
+		Convey("When GetOrLoad is called concurrently by multiple callers", func() {
+			var wg sync.WaitGroup
+			wg.Add(10)
+			for i := 0; i < 10; i++ {
+				go func() {
+					defer wg.Done()
+					_, _ = testCache.GetOrLoad(ctx, "key")
+				}()
+			}
+			wg.Wait()
+
+			Convey("Then the update function only runs once", func() {
+				So(atomic.LoadInt32(&calls), ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("Given a cache with no update function registered for a key", t, func() {
+		testCache, err := NewCache(ctx, Config{})
+		So(err, ShouldBeNil)
+
+		Convey("When GetOrLoad is called", func() {
+			_, err := testCache.GetOrLoad(ctx, "missing")
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("Given a cache with a cached value already present", t, func() {
+		testCache, err := NewCache(ctx, Config{})
+		So(err, ShouldBeNil)
+		testCache.Set("key", "cached")
+
+		called := false
+		testCache.AddUpdateFunc("key", func() (interface{}, error) {
+			called = true
+			return "loaded", nil
+		})
+
+		Convey("When GetOrLoad is called", func() {
+			value, err := testCache.GetOrLoad(ctx, "key")
+
+			Convey("Then the cached value is returned without invoking the update function", func() {
+				So(err, ShouldBeNil)
+				So(value, ShouldEqual, "cached")
+				So(called, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestAddUpdateFuncCtx(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given an update function registered via AddUpdateFuncCtx", t, func() {
+		testCache, err := NewCache(ctx, Config{})
+		So(err, ShouldBeNil)
+
+		var receivedCtx context.Context
+		type ctxKey struct{}
+		wantCtx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+		testCache.AddUpdateFuncCtx("key", func(ctx context.Context) (interface{}, error) {
+			receivedCtx = ctx
+			return "loaded", nil
+		})
+
+		Convey("When UpdateContent is called with a context", func() {
+			err := testCache.UpdateContent(wantCtx)
+
+			Convey("Then the update function receives that context", func() {
+				So(err, ShouldBeNil)
+				So(receivedCtx, ShouldEqual, wantCtx)
+			})
+		})
+	})
+}
+
+func TestGetOrLoadAndUpdateContentDeduplicate(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a key whose update function is slow", t, func() {
+		testCache, err := NewCache(ctx, Config{})
+		So(err, ShouldBeNil)
+
+		var calls int32
+		release := make(chan struct{})
+		testCache.AddUpdateFuncCtx("key", func(ctx context.Context) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return "loaded", nil
+		})
+
+		Convey("When GetOrLoad and UpdateContent race for the same key", func() {
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_, _ = testCache.GetOrLoad(ctx, "key")
+			}()
+			go func() {
+				defer wg.Done()
+				_ = testCache.UpdateContent(ctx)
+			}()
+
+			// give both goroutines a chance to reach the update function
+			// before releasing it, so they collapse into one call.
+			time.Sleep(10 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			Convey("Then the update function only runs once", func() {
+				So(atomic.LoadInt32(&calls), ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestUpdateContentConcurrentKeysDoNotRace(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache with many keys due for refresh at once", t, func() {
+		testCache, err := NewCache(ctx, Config{})
+		So(err, ShouldBeNil)
+
+		for i := 0; i < 50; i++ {
+			key := fmt.Sprintf("key-%d", i)
+			testCache.AddUpdateFuncCtx(key, func(ctx context.Context) (interface{}, error) {
+				return "updated", nil
+			})
+		}
+
+		Convey("When UpdateContent refreshes them all concurrently (the default UpdateConcurrency of 0)", func() {
+			err := testCache.UpdateContent(ctx)
+
+			Convey("Then it completes without racing on the shared update-function maps", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestUpdateContentErrorIsolation(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache with one failing and one succeeding update function", t, func() {
+		testCache, err := NewCache(ctx, Config{})
+		So(err, ShouldBeNil)
+
+		testCache.AddUpdateFunc("good", func() (interface{}, error) {
+			return "updated", nil
+		})
+		testCache.AddUpdateFunc("bad", func() (interface{}, error) {
+			return nil, errors.New("boom")
+		})
+
+		Convey("When UpdateContent is called", func() {
+			err := testCache.UpdateContent(ctx)
+
+			Convey("Then an error is returned but the good key is still updated", func() {
+				So(err, ShouldNotBeNil)
+
+				value, ok := testCache.Get("good")
+				So(ok, ShouldBeTrue)
+				So(value, ShouldEqual, "updated")
+			})
+		})
+	})
+}
+
+func TestDefaultTTL(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache configured with a DefaultTTL", t, func() {
+		testCache, err := NewCache(ctx, Config{DefaultTTL: -time.Second})
+		So(err, ShouldBeNil)
+
+		Convey("When Set is called without an explicit TTL", func() {
+			testCache.Set("key", "value")
+
+			Convey("Then the entry is stored already expired", func() {
+				_, ok := testCache.Get("key")
+				So(ok, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestOnEviction(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache bounded to one entry per shard with an OnEviction callback", t, func() {
+		testCache, err := NewCache(ctx, Config{MaxEntries: numShards})
+		So(err, ShouldBeNil)
+
+		var mu sync.Mutex
+		var evictedReasons []EvictionReason
+		testCache.OnEviction(func(key string, value interface{}, reason EvictionReason) {
+			mu.Lock()
+			defer mu.Unlock()
+			evictedReasons = append(evictedReasons, reason)
+		})
+
+		Convey("When more keys are set than the cache can hold", func() {
+			for i := 0; i < numShards*2; i++ {
+				testCache.Set(fmt.Sprintf("key-%d", i), i)
+			}
+
+			Convey("Then the callback is invoked with EvictedCapacity", func() {
+				mu.Lock()
+				defer mu.Unlock()
+				So(len(evictedReasons), ShouldBeGreaterThan, 0)
+				for _, reason := range evictedReasons {
+					So(reason, ShouldEqual, EvictedCapacity)
+				}
+			})
+		})
+
+		Convey("When a key is removed with Delete", func() {
+			testCache.Set("manual", "value")
+			testCache.Delete("manual")
+
+			Convey("Then the callback is invoked with EvictedManual and the key is gone", func() {
+				mu.Lock()
+				defer mu.Unlock()
+				So(evictedReasons, ShouldResemble, []EvictionReason{EvictedManual})
+
+				_, ok := testCache.Get("manual")
+				So(ok, ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("Given a cache with a janitor and an OnEviction callback", t, func() {
+		interval := time.Millisecond
+		testCache, err := NewCache(ctx, Config{CleanupInterval: &interval})
+		So(err, ShouldBeNil)
+
+		var mu sync.Mutex
+		var evictedReasons []EvictionReason
+		testCache.OnEviction(func(key string, value interface{}, reason EvictionReason) {
+			mu.Lock()
+			defer mu.Unlock()
+			evictedReasons = append(evictedReasons, reason)
+		})
+
+		Convey("When an entry's TTL elapses and the janitor sweeps it", func() {
+			testCache.SetWithTTL("ttl-key", "value", time.Nanosecond)
+			testCache.StartUpdates(ctx, make(chan error, 1))
+			defer testCache.Close()
+
+			found := false
+			for i := 0; i < 100; i++ {
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				found = len(evictedReasons) > 0
+				mu.Unlock()
+				if found {
+					break
+				}
+			}
+
+			Convey("Then the callback is invoked with EvictedExpired", func() {
+				mu.Lock()
+				defer mu.Unlock()
+				So(found, ShouldBeTrue)
+				So(evictedReasons[0], ShouldEqual, EvictedExpired)
+			})
+		})
+	})
+
+	Convey("Given a cache with no CleanupInterval and an OnEviction callback", t, func() {
+		testCache, err := NewCache(ctx, Config{})
+		So(err, ShouldBeNil)
+
+		var mu sync.Mutex
+		var evictedReasons []EvictionReason
+		testCache.OnEviction(func(key string, value interface{}, reason EvictionReason) {
+			mu.Lock()
+			defer mu.Unlock()
+			evictedReasons = append(evictedReasons, reason)
+		})
+
+		testCache.SetWithTTL("ttl-key", "value", time.Nanosecond)
+
+		Convey("When the entry's TTL elapses but nothing reads it", func() {
+			time.Sleep(10 * time.Millisecond)
+
+			Convey("Then the callback has not fired - eviction is only noticed lazily, on read", func() {
+				mu.Lock()
+				So(evictedReasons, ShouldBeEmpty)
+				mu.Unlock()
+
+				_, ok := testCache.Get("ttl-key")
+				So(ok, ShouldBeFalse)
+
+				mu.Lock()
+				defer mu.Unlock()
+				So(evictedReasons, ShouldResemble, []EvictionReason{EvictedExpired})
+			})
+		})
+	})
+}
+
+func TestRefreshAhead(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache with RefreshAhead set and a key whose TTL isn't close to elapsing", t, func() {
+		testCache, err := NewCache(ctx, Config{RefreshAhead: time.Millisecond})
+		So(err, ShouldBeNil)
+
+		var calls int32
+		testCache.AddUpdateFuncWithTTL("key", func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return "updated", nil
+		}, time.Hour)
+
+		Convey("When UpdateContent is called twice in a row", func() {
+			So(testCache.UpdateContent(ctx), ShouldBeNil)
+			So(testCache.UpdateContent(ctx), ShouldBeNil)
+
+			Convey("Then the update function only runs on the first call", func() {
+				So(atomic.LoadInt32(&calls), ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("Given a cache with RefreshAhead set and a key with no per-key TTL", t, func() {
+		testCache, err := NewCache(ctx, Config{RefreshAhead: time.Millisecond})
+		So(err, ShouldBeNil)
+
+		var calls int32
+		testCache.AddUpdateFunc("key", func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return "updated", nil
+		})
+
+		Convey("When UpdateContent is called twice in a row", func() {
+			So(testCache.UpdateContent(ctx), ShouldBeNil)
+			So(testCache.UpdateContent(ctx), ShouldBeNil)
+
+			Convey("Then the update function runs on every call, as it has no expiry to be ahead of", func() {
+				So(atomic.LoadInt32(&calls), ShouldEqual, 2)
+			})
+		})
+	})
+}
+
+func TestGetWithMeta(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache containing an entry", t, func() {
+		testCache, err := NewCache(ctx, Config{})
+		So(err, ShouldBeNil)
+
+		testCache.Set("key", "value")
+
+		Convey("When GetWithMeta is called", func() {
+			value, meta, ok := testCache.GetWithMeta("key")
+
+			Convey("Then the value is returned along with its last-updated time and no error", func() {
+				So(ok, ShouldBeTrue)
+				So(value, ShouldEqual, "value")
+				So(meta.LastUpdated, ShouldHappenBefore, time.Now())
+				So(meta.LastError, ShouldBeNil)
+				So(meta.Stale, ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("Given a cache with no entry for a key", t, func() {
+		testCache, err := NewCache(ctx, Config{})
+		So(err, ShouldBeNil)
+
+		Convey("When GetWithMeta is called", func() {
+			value, meta, ok := testCache.GetWithMeta("missing")
+
+			Convey("Then ok is false and meta is the zero value", func() {
+				So(ok, ShouldBeFalse)
+				So(value, ShouldBeNil)
+				So(meta, ShouldResemble, Meta{})
+			})
+		})
+	})
+}
+
+func TestReturnLastGood(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache configured with ReturnLastGood and a key that has an existing value", t, func() {
+		testCache, err := NewCache(ctx, Config{ReturnLastGood: true})
+		So(err, ShouldBeNil)
+
+		testCache.Set("key", "good value")
+
+		failErr := errors.New("upstream unavailable")
+		testCache.AddUpdateFunc("key", func() (interface{}, error) {
+			return nil, failErr
+		})
+
+		var mu sync.Mutex
+		var results []string
+		testCache.OnUpdateResult(func(key string, err error, stale bool) {
+			mu.Lock()
+			defer mu.Unlock()
+			results = append(results, fmt.Sprintf("%s:%v:%v", key, err, stale))
+		})
+
+		Convey("When UpdateContent fails for that key", func() {
+			err := testCache.UpdateContent(ctx)
+			So(err, ShouldNotBeNil)
+
+			Convey("Then the last good value is still served, flagged as stale with its error", func() {
+				value, meta, ok := testCache.GetWithMeta("key")
+				So(ok, ShouldBeTrue)
+				So(value, ShouldEqual, "good value")
+				So(meta.Stale, ShouldBeTrue)
+				So(meta.LastError, ShouldEqual, failErr)
+
+				mu.Lock()
+				defer mu.Unlock()
+				So(results, ShouldResemble, []string{fmt.Sprintf("key:%v:true", failErr)})
+			})
+		})
+	})
+
+	Convey("Given a cache without ReturnLastGood and a key that has an existing value", t, func() {
+		testCache, err := NewCache(ctx, Config{})
+		So(err, ShouldBeNil)
+
+		testCache.Set("key", "good value")
+
+		testCache.AddUpdateFunc("key", func() (interface{}, error) {
+			return nil, errors.New("upstream unavailable")
+		})
+
+		Convey("When UpdateContent fails for that key", func() {
+			err := testCache.UpdateContent(ctx)
+			So(err, ShouldNotBeNil)
+
+			Convey("Then the stale value is removed rather than kept", func() {
+				_, ok := testCache.Get("key")
+				So(ok, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestServeExpired(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache configured to serve expired entries within MaxStale", t, func() {
+		testCache, err := NewCache(ctx, Config{ServeExpired: true, MaxStale: time.Hour})
+		So(err, ShouldBeNil)
+
+		testCache.SetWithTTL("key", "value", -time.Second)
+
+		Convey("When Get is called after the entry's TTL has elapsed", func() {
+			value, ok := testCache.Get("key")
+
+			Convey("Then the stale value is still served", func() {
+				So(ok, ShouldBeTrue)
+				So(value, ShouldEqual, "value")
+			})
+		})
+
+		Convey("When GetWithMeta is called after the entry's TTL has elapsed", func() {
+			value, meta, ok := testCache.GetWithMeta("key")
+
+			Convey("Then the stale value is served flagged as stale", func() {
+				So(ok, ShouldBeTrue)
+				So(value, ShouldEqual, "value")
+				So(meta.Stale, ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given a cache configured to serve expired entries, and an entry expired for longer than MaxStale", t, func() {
+		testCache, err := NewCache(ctx, Config{ServeExpired: true, MaxStale: time.Millisecond})
+		So(err, ShouldBeNil)
+
+		testCache.SetWithTTL("key", "value", -time.Hour)
+
+		Convey("When Get is called once the staleness window has also elapsed", func() {
+			_, ok := testCache.Get("key")
+
+			Convey("Then the entry is no longer served", func() {
+				So(ok, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestRegisterTypeAndGetTyped(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache with a registered type", t, func() {
+		testCache, err := NewCache(ctx, Config{})
+		So(err, ShouldBeNil)
+
+		var calls int32
+		testCache.RegisterType("dataset", func(ctx context.Context, req interface{}) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return fmt.Sprintf("dataset-%v", req), nil
+		}, TypeOptions{TTL: time.Hour})
+
+		Convey("When GetTyped is called for two different requests", func() {
+			valueA, err := testCache.GetTyped(ctx, "dataset", "a")
+			So(err, ShouldBeNil)
+			valueB, err := testCache.GetTyped(ctx, "dataset", "b")
+			So(err, ShouldBeNil)
+
+			Convey("Then each request gets its own entry, populated by the shared fetcher", func() {
+				So(valueA, ShouldEqual, "dataset-a")
+				So(valueB, ShouldEqual, "dataset-b")
+				So(atomic.LoadInt32(&calls), ShouldEqual, 2)
+			})
+		})
+
+		Convey("When GetTyped is called twice for the same request", func() {
+			_, err := testCache.GetTyped(ctx, "dataset", "a")
+			So(err, ShouldBeNil)
+			_, err = testCache.GetTyped(ctx, "dataset", "a")
+			So(err, ShouldBeNil)
+
+			Convey("Then the fetcher only runs once, the second call being served from cache", func() {
+				So(atomic.LoadInt32(&calls), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When GetTyped is called for an unregistered type", func() {
+			_, err := testCache.GetTyped(ctx, "missing", "a")
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("Given a cache with a registered type bounded to one entry", t, func() {
+		testCache, err := NewCache(ctx, Config{})
+		So(err, ShouldBeNil)
+
+		testCache.RegisterType("dataset", func(ctx context.Context, req interface{}) (interface{}, error) {
+			return fmt.Sprintf("dataset-%v", req), nil
+		}, TypeOptions{TTL: time.Hour, MaxEntries: 1})
+
+		Convey("When GetTyped is called for a second request", func() {
+			_, err := testCache.GetTyped(ctx, "dataset", "a")
+			So(err, ShouldBeNil)
+			_, err = testCache.GetTyped(ctx, "dataset", "b")
+			So(err, ShouldBeNil)
+
+			Convey("Then the first request's entry is evicted", func() {
+				_, ok := testCache.Get("dataset:\"a\"")
+				So(ok, ShouldBeFalse)
+
+				value, ok := testCache.Get("dataset:\"b\"")
+				So(ok, ShouldBeTrue)
+				So(value, ShouldEqual, "dataset-b")
+			})
+		})
+	})
+
+	Convey("Given a cache with a registered type using a custom Key function", t, func() {
+		testCache, err := NewCache(ctx, Config{})
+		So(err, ShouldBeNil)
+
+		type request struct {
+			ID int
+		}
+		testCache.RegisterType("widget", func(ctx context.Context, req interface{}) (interface{}, error) {
+			return req.(request).ID * 2, nil
+		}, TypeOptions{
+			TTL: time.Hour,
+			Key: func(req interface{}) string {
+				return fmt.Sprintf("%d", req.(request).ID)
+			},
+		})
+
+		Convey("When GetTyped is called", func() {
+			value, err := testCache.GetTyped(ctx, "widget", request{ID: 21})
+
+			Convey("Then the fetcher result for that key is returned", func() {
+				So(err, ShouldBeNil)
+				So(value, ShouldEqual, 42)
+			})
+		})
+	})
+}
+
+func TestGetTypedAndUpdateContentDoNotRace(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache with a registered type and some keys already due for refresh", t, func() {
+		testCache, err := NewCache(ctx, Config{})
+		So(err, ShouldBeNil)
+
+		for i := 0; i < 10; i++ {
+			key := fmt.Sprintf("key-%d", i)
+			testCache.AddUpdateFuncCtx(key, func(ctx context.Context) (interface{}, error) {
+				return "updated", nil
+			})
+		}
+
+		testCache.RegisterType("dataset", func(ctx context.Context, req interface{}) (interface{}, error) {
+			return fmt.Sprintf("dataset-%v", req), nil
+		}, TypeOptions{TTL: time.Hour})
+
+		Convey("When GetTyped registers new keys while UpdateContent is refreshing the cache", func() {
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 10; i++ {
+					_, _ = testCache.GetTyped(ctx, "dataset", i)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				_ = testCache.UpdateContent(ctx)
+			}()
+			wg.Wait()
+
+			Convey("Then both finish without racing on the shared maps", func() {
+				value, err := testCache.GetTyped(ctx, "dataset", 0)
+				So(err, ShouldBeNil)
+				So(value, ShouldEqual, "dataset-0")
+			})
+		})
+	})
+}
+
+func TestOnUpdateResult(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache with an OnUpdateResult callback and a succeeding update function", t, func() {
+		testCache, err := NewCache(ctx, Config{})
+		So(err, ShouldBeNil)
+
+		var mu sync.Mutex
+		var results []string
+		testCache.OnUpdateResult(func(key string, err error, stale bool) {
+			mu.Lock()
+			defer mu.Unlock()
+			results = append(results, fmt.Sprintf("%s:%v:%v", key, err, stale))
+		})
+
+		testCache.AddUpdateFunc("key", func() (interface{}, error) {
+			return "updated", nil
+		})
+
+		Convey("When UpdateContent runs", func() {
+			So(testCache.UpdateContent(ctx), ShouldBeNil)
+
+			Convey("Then the callback is invoked with a nil error and stale false", func() {
+				mu.Lock()
+				defer mu.Unlock()
+				So(results, ShouldResemble, []string{"key:<nil>:false"})
+			})
+		})
+	})
+}
+
+// fakeStore is a minimal in-memory dpcache.Store used to test Config.Store
+// write-through/read-through without depending on any of the store
+// subpackages, which would create an import cycle.
+type fakeStore struct {
+	mu    sync.Mutex
+	items map[string][]byte
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{items: make(map[string][]byte)}
+}
+
+func (f *fakeStore) Get(key string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, ok := f.items[key]
+	return value, ok
+}
+
+func (f *fakeStore) Set(key string, value []byte, _ time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.items[key] = value
+	return nil
+}
+
+func (f *fakeStore) Delete(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.items, key)
+	return nil
+}
+
+func (f *fakeStore) Range(fn func(key string, value []byte) bool) {
+	f.mu.Lock()
+	items := make(map[string][]byte, len(f.items))
+	for key, value := range f.items {
+		items[key] = value
+	}
+	f.mu.Unlock()
+
+	for key, value := range items {
+		if !fn(key, value) {
+			return
+		}
+	}
+}
+
+func (f *fakeStore) Close() error {
+	return nil
+}
+
+func TestConfigStore(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache configured with a Store", t, func() {
+		store := newFakeStore()
+		testCache, err := NewCache(ctx, Config{Store: store})
+		So(err, ShouldBeNil)
+
+		Convey("When Set is called", func() {
+			testCache.Set("key", "value")
+
+			Convey("Then the value is written through to the store, JSON-encoded", func() {
+				raw, ok := store.Get("key")
+				So(ok, ShouldBeTrue)
+				So(string(raw), ShouldEqual, `"value"`)
+			})
+		})
+
+		Convey("When a value exists only in the store", func() {
+			encoded, err := JSONCodec{}.Encode("from store")
+			So(err, ShouldBeNil)
+			So(store.Set("key", encoded, 0), ShouldBeNil)
+
+			Convey("Then Get reads it through and repopulates the local cache", func() {
+				value, ok := testCache.Get("key")
+				So(ok, ShouldBeTrue)
+				So(value, ShouldEqual, "from store")
+				So(testCache.entryPresent("key"), ShouldBeTrue)
+			})
+		})
+
+		Convey("When Delete is called", func() {
+			testCache.Set("key", "value")
+			testCache.Delete("key")
+
+			Convey("Then the value is also removed from the store", func() {
+				_, ok := store.Get("key")
+				So(ok, ShouldBeFalse)
+			})
+		})
+
+		Convey("When Close is called with an update func registered", func() {
+			updateInterval := time.Hour
+			testCache.config.UpdateInterval = &updateInterval
+			testCache.AddUpdateFuncCtx("key", func(context.Context) (interface{}, error) { return "value", nil })
+			testCache.Set("key", "value")
+			testCache.StartUpdates(ctx, make(chan error, 1))
+
+			testCache.Close()
+
+			Convey("Then the shared store is left untouched, so other replicas still reading through it are unaffected", func() {
+				raw, ok := store.Get("key")
+				So(ok, ShouldBeTrue)
+				So(string(raw), ShouldEqual, `"value"`)
+			})
+		})
+	})
+
+	Convey("Given a cache configured with a Store and a GobCodec", t, func() {
+		store := newFakeStore()
+		testCache, err := NewCache(ctx, Config{Store: store, Codec: GobCodec{}})
+		So(err, ShouldBeNil)
+
+		Convey("When Set is called and the value is read back from the store directly", func() {
+			testCache.Set("key", "value")
+
+			raw, ok := store.Get("key")
+			So(ok, ShouldBeTrue)
+
+			decoded, err := GobCodec{}.Decode(raw)
+
+			Convey("Then it round-trips through GobCodec", func() {
+				So(err, ShouldBeNil)
+				So(decoded, ShouldEqual, "value")
+			})
+		})
+	})
+}
+
+func TestCodecs(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a value encoded with JSONCodec", t, func() {
+		encoded, err := JSONCodec{}.Encode(map[string]interface{}{"a": float64(1)})
+		So(err, ShouldBeNil)
+
+		Convey("When it is decoded", func() {
+			value, err := JSONCodec{}.Decode(encoded)
+
+			Convey("Then it round-trips", func() {
+				So(err, ShouldBeNil)
+				So(value, ShouldResemble, map[string]interface{}{"a": float64(1)})
+			})
+		})
+	})
+
+	Convey("Given a value encoded with GobCodec", t, func() {
+		encoded, err := GobCodec{}.Encode("gob value")
+		So(err, ShouldBeNil)
+
+		Convey("When it is decoded", func() {
+			value, err := GobCodec{}.Decode(encoded)
+
+			Convey("Then it round-trips", func() {
+				So(err, ShouldBeNil)
+				So(value, ShouldEqual, "gob value")
+			})
+		})
+	})
+}
@@ -0,0 +1,279 @@
+// Package dbcache provides a SQLite-backed implementation of dpcache.Cacher
+// that persists entries to a local file, giving a service a warm cache that
+// survives restarts instead of starting from empty every time.
+package dbcache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	dpcache "github.com/ONSdigital/dp-cache"
+	"github.com/ONSdigital/log.go/v2/log"
+	_ "modernc.org/sqlite"
+)
+
+const minCleanupInterval = 60 * time.Second
+
+const schema = `
+CREATE TABLE IF NOT EXISTS entries (
+	key        TEXT PRIMARY KEY,
+	value      BLOB NOT NULL,
+	created_at INTEGER NOT NULL,
+	expires_at INTEGER NOT NULL
+)`
+
+// Config configures the SQLite-backed cache.
+type Config struct {
+	// Path is the SQLite file to store entries in, e.g. "/data/cache.db".
+	Path string
+
+	// CacheTTL is how long a Set entry remains valid for.
+	CacheTTL time.Duration
+
+	// CleanupInterval controls how often expired rows are purged. If zero,
+	// it defaults to one-fifth of CacheTTL, with a floor of
+	// minCleanupInterval.
+	CleanupInterval time.Duration
+
+	UpdateInterval *time.Duration
+}
+
+// Stats holds counters describing cache activity.
+type Stats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+}
+
+// Cache contains all the information to start, update and close a
+// SQLite-backed cache.
+type Cache struct {
+	db          *sql.DB
+	config      Config
+	close       chan struct{}
+	mu          sync.Mutex
+	updateFuncs map[string]func() (interface{}, error)
+	stats       Stats
+
+	// started records whether StartUpdates has been called, so Close knows
+	// whether there's a background loop listening on close - a cache used
+	// purely for Get/Set, with StartUpdates never called, has nothing
+	// reading from it.
+	started bool
+}
+
+// ensure Cache satisfies dpcache.Cacher at compile time.
+var _ dpcache.Cacher = (*Cache)(nil)
+
+// NewCache opens (creating if necessary) the SQLite file at config.Path and
+// returns a Cache backed by it.
+func NewCache(ctx context.Context, config Config) (*Cache, error) {
+	if config.CacheTTL <= 0 {
+		err := fmt.Errorf("cache TTL duration is less than or equal to 0")
+		log.Error(ctx, "invalid dbcache config", err)
+		return nil, err
+	}
+
+	if config.CleanupInterval <= 0 {
+		config.CleanupInterval = config.CacheTTL / 5
+	}
+	if config.CleanupInterval < minCleanupInterval {
+		config.CleanupInterval = minCleanupInterval
+	}
+
+	db, err := sql.Open("sqlite", config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite cache at %s: %w", config.Path, err)
+	}
+
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create sqlite cache schema: %w", err)
+	}
+
+	return &Cache{
+		db:          db,
+		config:      config,
+		close:       make(chan struct{}),
+		updateFuncs: make(map[string]func() (interface{}, error)),
+	}, nil
+}
+
+// Get retrieves and JSON-decodes the value stored for key, filtering out
+// (and lazily deleting) expired rows.
+func (dc *Cache) Get(key string) (interface{}, bool) {
+	ctx := context.Background()
+
+	var raw []byte
+	var expiresAt int64
+	row := dc.db.QueryRowContext(ctx, "SELECT value, expires_at FROM entries WHERE key = ?", key)
+	if err := row.Scan(&raw, &expiresAt); err != nil {
+		atomic.AddInt64(&dc.stats.Misses, 1)
+		return nil, false
+	}
+
+	if time.Now().Unix() >= expiresAt {
+		dc.delete(ctx, key)
+		atomic.AddInt64(&dc.stats.Misses, 1)
+		atomic.AddInt64(&dc.stats.Evictions, 1)
+		return nil, false
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		atomic.AddInt64(&dc.stats.Misses, 1)
+		return nil, false
+	}
+
+	atomic.AddInt64(&dc.stats.Hits, 1)
+	return value, true
+}
+
+// Set JSON-encodes data and upserts it against key, expiring it after
+// Config.CacheTTL.
+func (dc *Cache) Set(key string, data interface{}) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		log.Error(context.Background(), "failed to marshal value for dbcache", err, log.Data{"key": key})
+		return
+	}
+
+	now := time.Now()
+	_, err = dc.db.ExecContext(context.Background(),
+		`INSERT INTO entries (key, value, created_at, expires_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT(key) DO UPDATE SET value = excluded.value, created_at = excluded.created_at, expires_at = excluded.expires_at`,
+		key, encoded, now.Unix(), now.Add(dc.config.CacheTTL).Unix())
+	if err != nil {
+		log.Error(context.Background(), "failed to set value in dbcache", err, log.Data{"key": key})
+	}
+}
+
+func (dc *Cache) delete(ctx context.Context, key string) {
+	if _, err := dc.db.ExecContext(ctx, "DELETE FROM entries WHERE key = ?", key); err != nil {
+		log.Error(ctx, "failed to delete expired dbcache entry", err, log.Data{"key": key})
+	}
+}
+
+// cleanup removes every row that has passed its expiry, incrementing
+// Stats.Evictions for each one removed.
+func (dc *Cache) cleanup(ctx context.Context) error {
+	result, err := dc.db.ExecContext(ctx, "DELETE FROM entries WHERE expires_at < ?", time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to clean up expired dbcache entries: %w", err)
+	}
+
+	if removed, err := result.RowsAffected(); err == nil {
+		atomic.AddInt64(&dc.stats.Evictions, removed)
+	}
+	return nil
+}
+
+// Stats returns a snapshot of the cache's hit/miss/eviction counters.
+func (dc *Cache) Stats() Stats {
+	return Stats{
+		Hits:      atomic.LoadInt64(&dc.stats.Hits),
+		Misses:    atomic.LoadInt64(&dc.stats.Misses),
+		Evictions: atomic.LoadInt64(&dc.stats.Evictions),
+	}
+}
+
+// Close stops the background cleanup and update loops, if StartUpdates was
+// called, and closes the underlying database handle. A Cache only ever used
+// for Get/Set, with StartUpdates never called, has no loop to stop.
+func (dc *Cache) Close() {
+	dc.mu.Lock()
+	started := dc.started
+	dc.updateFuncs = make(map[string]func() (interface{}, error))
+	dc.mu.Unlock()
+
+	if started {
+		dc.close <- struct{}{}
+	}
+
+	if err := dc.db.Close(); err != nil {
+		log.Error(context.Background(), "failed to close dbcache database", err)
+	}
+}
+
+// AddUpdateFunc adds an update function to the cache for a specific data
+// corresponding to the `key` passed to the function. This update function
+// will then be triggered once or at every fixed interval as per the prior
+// setup of the Cache.
+func (dc *Cache) AddUpdateFunc(key string, updateFunc func() (interface{}, error)) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.updateFuncs[key] = updateFunc
+}
+
+// UpdateContent calls all the update functions with a key value stored in
+// the Cache to update the relevant data with the same key values.
+func (dc *Cache) UpdateContent(_ context.Context) error {
+	dc.mu.Lock()
+	updateFuncs := make(map[string]func() (interface{}, error), len(dc.updateFuncs))
+	for key, updateFunc := range dc.updateFuncs {
+		updateFuncs[key] = updateFunc
+	}
+	dc.mu.Unlock()
+
+	for key, updateFunc := range updateFuncs {
+		updatedContent, err := updateFunc()
+		if err != nil {
+			return fmt.Errorf("failed to update dbcache for %s. error: %v", key, err)
+		}
+		dc.Set(key, updatedContent)
+	}
+	return nil
+}
+
+// StartUpdates starts the background cleanup loop, which runs every
+// Config.CleanupInterval, and, if update functions have been registered and
+// an UpdateInterval configured, the periodic update loop alongside it.
+func (dc *Cache) StartUpdates(ctx context.Context, errorChannel chan error) {
+	dc.mu.Lock()
+	dc.started = true
+	dc.mu.Unlock()
+
+	go func() {
+		cleanupTicker := time.NewTicker(dc.config.CleanupInterval)
+		defer cleanupTicker.Stop()
+
+		var updateTicker *time.Ticker
+		dc.mu.Lock()
+		hasUpdateFuncs := len(dc.updateFuncs) > 0
+		dc.mu.Unlock()
+
+		if hasUpdateFuncs && dc.config.UpdateInterval != nil {
+			updateTicker = time.NewTicker(*dc.config.UpdateInterval)
+			defer updateTicker.Stop()
+		}
+
+		var updateTickerChan <-chan time.Time
+		if updateTicker != nil {
+			updateTickerChan = updateTicker.C
+		}
+
+		for {
+			select {
+			case <-cleanupTicker.C:
+				if err := dc.cleanup(ctx); err != nil {
+					log.Error(ctx, err.Error(), err)
+					errorChannel <- err
+				}
+			case <-updateTickerChan:
+				if err := dc.UpdateContent(ctx); err != nil {
+					log.Error(ctx, err.Error(), err)
+					errorChannel <- err
+				}
+			case <-dc.close:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,194 @@
+package dbcache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func getTestConfig(t *testing.T) Config {
+	return Config{
+		Path:     filepath.Join(t.TempDir(), "cache.db"),
+		CacheTTL: time.Hour,
+	}
+}
+
+func TestNewCache(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a valid config", t, func() {
+		config := getTestConfig(t)
+
+		Convey("When NewCache is called", func() {
+			testCache, err := NewCache(ctx, config)
+
+			Convey("Then a cache object should be successfully returned", func() {
+				So(testCache, ShouldNotBeNil)
+				So(err, ShouldBeNil)
+
+				Convey("And the cleanup interval should default to one-fifth of the TTL", func() {
+					So(testCache.config.CleanupInterval, ShouldEqual, config.CacheTTL/5)
+				})
+			})
+		})
+	})
+
+	Convey("Given a CacheTTL whose fifth is below the minimum cleanup interval", t, func() {
+		config := getTestConfig(t)
+		config.CacheTTL = time.Second
+
+		Convey("When NewCache is called", func() {
+			testCache, err := NewCache(ctx, config)
+
+			Convey("Then the cleanup interval should be floored at the minimum", func() {
+				So(err, ShouldBeNil)
+				So(testCache.config.CleanupInterval, ShouldEqual, minCleanupInterval)
+			})
+		})
+	})
+
+	Convey("Given an invalid TTL", t, func() {
+		config := getTestConfig(t)
+		config.CacheTTL = 0
+
+		Convey("When NewCache is called", func() {
+			testCache, err := NewCache(ctx, config)
+
+			Convey("Then an error should be returned", func() {
+				So(testCache, ShouldBeNil)
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestGetAndSet(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	config := getTestConfig(t)
+
+	testCache, err := NewCache(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer testCache.db.Close()
+
+	Convey("Given a cache", t, func() {
+		Convey("When Set is called followed by Get", func() {
+			testCache.Set("string", "test")
+
+			Convey("Then the stored value should be retrievable and a hit recorded", func() {
+				value, ok := testCache.Get("string")
+				So(ok, ShouldBeTrue)
+				So(value, ShouldEqual, "test")
+				So(testCache.Stats().Hits, ShouldBeGreaterThan, 0)
+			})
+		})
+
+		Convey("When Get is called for a key that was never set", func() {
+			_, ok := testCache.Get("missing")
+
+			Convey("Then ok should be false and a miss recorded", func() {
+				So(ok, ShouldBeFalse)
+				So(testCache.Stats().Misses, ShouldBeGreaterThan, 0)
+			})
+		})
+
+		Convey("When Get is called for an expired entry", func() {
+			testCache.config.CacheTTL = -time.Second
+			testCache.Set("expired", "test")
+
+			_, ok := testCache.Get("expired")
+
+			Convey("Then ok should be false and an eviction recorded", func() {
+				So(ok, ShouldBeFalse)
+				So(testCache.Stats().Evictions, ShouldBeGreaterThan, 0)
+			})
+		})
+	})
+}
+
+func TestCleanup(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	config := getTestConfig(t)
+
+	testCache, err := NewCache(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer testCache.db.Close()
+
+	Convey("Given a cache containing only expired entries", t, func() {
+		testCache.config.CacheTTL = -time.Second
+		testCache.Set("expired", "test")
+
+		Convey("When cleanup is called", func() {
+			err := testCache.cleanup(ctx)
+
+			Convey("Then no error is returned and the entry is evicted", func() {
+				So(err, ShouldBeNil)
+				So(testCache.Stats().Evictions, ShouldBeGreaterThan, 0)
+
+				var count int
+				row := testCache.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM entries")
+				So(row.Scan(&count), ShouldBeNil)
+				So(count, ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache that StartUpdates was never called on", t, func() {
+		testCache, err := NewCache(ctx, getTestConfig(t))
+		So(err, ShouldBeNil)
+
+		Convey("When Close is called", func() {
+			done := make(chan struct{})
+			go func() {
+				testCache.Close()
+				close(done)
+			}()
+
+			Convey("Then it returns without blocking on a receiver that was never started", func() {
+				select {
+				case <-done:
+				case <-time.After(time.Second):
+					t.Fatal("Close blocked waiting for a StartUpdates loop that was never started")
+				}
+			})
+		})
+	})
+
+	Convey("Given a cache with StartUpdates called", t, func() {
+		testCache, err := NewCache(ctx, getTestConfig(t))
+		So(err, ShouldBeNil)
+
+		errorChan := make(chan error, 1)
+		testCache.StartUpdates(ctx, errorChan)
+
+		Convey("When Close is called", func() {
+			done := make(chan struct{})
+			go func() {
+				testCache.Close()
+				close(done)
+			}()
+
+			Convey("Then it stops the background loop and returns", func() {
+				select {
+				case <-done:
+				case <-time.After(time.Second):
+					t.Fatal("Close blocked despite StartUpdates having been called")
+				}
+			})
+		})
+	})
+}
@@ -69,7 +69,7 @@ func (dc *TopicCache) GetData(ctx context.Context, key string) (*TopicItem, erro
 // AddUpdateFunc adds an update function to the topic cache for a topic with the `key` passed to the function
 // This update function will then be triggered once or at every fixed interval as per the prior setup of the TopicCache
 func (dc *TopicCache) AddUpdateFunc(key string, updateFunc func() *TopicItem) {
-	dc.UpdateFuncs[key] = func() (interface{}, error) {
+	dc.UpdateFuncs[key] = func(ctx context.Context) (interface{}, error) {
 		// error handling is done within the updateFunc
 		return updateFunc(), nil
 	}
@@ -0,0 +1,195 @@
+package cache
+
+import (
+	"container/list"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// numShards is the number of independently-locked stripes the cache data is
+// split across. Splitting the LRU store this way keeps lock contention low
+// on the hot Get/Set path compared with guarding a single shared structure.
+const numShards = 16
+
+// lruEntry is the value held in a shard's doubly-linked list, front being
+// the most recently used and back the least.
+type lruEntry struct {
+	key   string
+	value entry
+	size  int
+}
+
+// shard is one stripe of the sharded LRU store backing Cache.
+type shard struct {
+	mu     sync.Mutex
+	items  map[string]*list.Element
+	order  *list.List
+	nbytes int
+}
+
+func newShard() *shard {
+	return &shard{
+		items: make(map[string]*list.Element),
+		order: list.New(),
+	}
+}
+
+func shardIndex(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % numShards)
+}
+
+// get looks up key, promoting it to the front of the shard's LRU order if
+// present and not expired. expired reports whether an entry was found but
+// had passed its TTL. If maxStale is positive and the entry has been
+// expired for no longer than maxStale, it is still returned (found=true,
+// stale=true) rather than removed, for Config.ServeExpired's benefit;
+// otherwise an expired entry is removed and reported as not found, and
+// onEvict, if non-nil, is called for it with the evicted value after the
+// shard lock has been released - the same lazy path is the only place an
+// unread, never-swept key's expiry is ever noticed, so this is also the only
+// chance EvictedExpired gets to fire for it without a janitor configured.
+func (s *shard) get(key string, now time.Time, maxStale time.Duration, onEvict func(key string, value entry)) (value entry, found bool, expired bool, stale bool) {
+	s.mu.Lock()
+
+	el, ok := s.items[key]
+	if !ok {
+		s.mu.Unlock()
+		return entry{}, false, false, false
+	}
+
+	le := el.Value.(*lruEntry)
+	if !le.value.expired(now) {
+		s.order.MoveToFront(el)
+		s.mu.Unlock()
+		return le.value, true, false, false
+	}
+
+	if maxStale > 0 && now.Sub(le.value.expiresAt) <= maxStale {
+		s.mu.Unlock()
+		return le.value, true, true, true
+	}
+
+	expiredValue := le.value
+	s.removeElement(el)
+	s.mu.Unlock()
+
+	if onEvict != nil {
+		onEvict(key, expiredValue)
+	}
+	return entry{}, false, true, false
+}
+
+// set inserts or updates key at the front of the LRU order, then evicts from
+// the back until the shard is within maxEntries/maxBytes (each 0 means
+// unbounded). The entry just set is never evicted, so a single entry larger
+// than maxBytes is kept. It returns the number of entries evicted to make room. If
+// onEvict is non-nil, it is called once per evicted entry after the shard
+// lock has been released, so callers can safely re-enter the cache from it.
+func (s *shard) set(key string, value entry, size, maxEntries, maxBytes int, onEvict func(key string, value entry)) int {
+	s.mu.Lock()
+
+	if el, ok := s.items[key]; ok {
+		old := el.Value.(*lruEntry)
+		s.nbytes += size - old.size
+		old.value, old.size = value, size
+		s.order.MoveToFront(el)
+	} else {
+		el := s.order.PushFront(&lruEntry{key: key, value: value, size: size})
+		s.items[key] = el
+		s.nbytes += size
+	}
+
+	var removed []lruEntry
+	for (maxEntries > 0 && len(s.items) > maxEntries) || (maxBytes > 0 && s.nbytes > maxBytes) {
+		back := s.order.Back()
+		if back == nil || len(s.items) == 1 {
+			break
+		}
+		removed = append(removed, *back.Value.(*lruEntry))
+		s.removeElement(back)
+	}
+	s.mu.Unlock()
+
+	if onEvict != nil {
+		for _, le := range removed {
+			onEvict(le.key, le.value)
+		}
+	}
+	return len(removed)
+}
+
+// removeExpired sweeps the shard for entries that have passed their TTL,
+// returning how many were removed. An entry within maxStale of expiring is
+// left in place so Config.ServeExpired can still serve it; pass 0 to remove
+// every expired entry regardless of how recently it expired. onEvict is
+// called as described in set.
+func (s *shard) removeExpired(now time.Time, maxStale time.Duration, onEvict func(key string, value entry)) int {
+	s.mu.Lock()
+
+	var removed []lruEntry
+	for el := s.order.Back(); el != nil; {
+		prev := el.Prev()
+		le := el.Value.(*lruEntry)
+		if le.value.expired(now) && (maxStale <= 0 || now.Sub(le.value.expiresAt) > maxStale) {
+			removed = append(removed, *le)
+			s.removeElement(el)
+		}
+		el = prev
+	}
+	s.mu.Unlock()
+
+	if onEvict != nil {
+		for _, le := range removed {
+			onEvict(le.key, le.value)
+		}
+	}
+	return len(removed)
+}
+
+// recordError attaches err to the entry stored for key, if present, without
+// touching its value or expiry, and reports whether there was such an entry.
+// Used to surface a failed refresh via GetWithMeta while
+// Config.ReturnLastGood keeps serving the last good value.
+func (s *shard) recordError(key string, err error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.items[key]
+	if !ok {
+		return false
+	}
+	el.Value.(*lruEntry).value.lastErr = err
+	return true
+}
+
+// delete removes key from the shard, returning its value if present.
+func (s *shard) delete(key string) (entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.items[key]
+	if !ok {
+		return entry{}, false
+	}
+
+	value := el.Value.(*lruEntry).value
+	s.removeElement(el)
+	return value, true
+}
+
+// len reports the number of entries currently held in the shard.
+func (s *shard) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+func (s *shard) removeElement(el *list.Element) {
+	le := el.Value.(*lruEntry)
+	delete(s.items, le.key)
+	s.nbytes -= le.size
+	s.order.Remove(el)
+}
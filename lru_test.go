@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestShardSet(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a shard with a max of 2 entries", t, func() {
+		s := newShard()
+
+		s.set("a", entry{value: "a"}, 0, 2, 0, nil)
+		s.set("b", entry{value: "b"}, 0, 2, 0, nil)
+
+		Convey("When a third entry is set", func() {
+			evicted := s.set("c", entry{value: "c"}, 0, 2, 0, nil)
+
+			Convey("Then the least-recently-used entry is evicted", func() {
+				So(evicted, ShouldEqual, 1)
+
+				_, foundA, _, _ := s.get("a", time.Now(), 0, nil)
+				So(foundA, ShouldBeFalse)
+
+				_, foundB, _, _ := s.get("b", time.Now(), 0, nil)
+				So(foundB, ShouldBeTrue)
+
+				_, foundC, _, _ := s.get("c", time.Now(), 0, nil)
+				So(foundC, ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given a shard with a max of 2 entries", t, func() {
+		s := newShard()
+
+		s.set("a", entry{value: "a"}, 0, 2, 0, nil)
+		s.set("b", entry{value: "b"}, 0, 2, 0, nil)
+
+		Convey("When the oldest entry is read before a third is set", func() {
+			_, _, _, _ = s.get("a", time.Now(), 0, nil)
+			s.set("c", entry{value: "c"}, 0, 2, 0, nil)
+
+			Convey("Then the entry that wasn't touched is evicted instead", func() {
+				_, foundA, _, _ := s.get("a", time.Now(), 0, nil)
+				So(foundA, ShouldBeTrue)
+
+				_, foundB, _, _ := s.get("b", time.Now(), 0, nil)
+				So(foundB, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestShardGetExpired(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a shard containing an expired entry", t, func() {
+		s := newShard()
+		s.set("a", entry{value: "a", expiresAt: time.Now().Add(-time.Second)}, 0, 0, 0, nil)
+
+		Convey("When get is called", func() {
+			_, found, expired, _ := s.get("a", time.Now(), 0, nil)
+
+			Convey("Then it is reported as expired rather than found", func() {
+				So(found, ShouldBeFalse)
+				So(expired, ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestShardRemoveExpired(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a shard with a mix of expired and live entries", t, func() {
+		s := newShard()
+		s.set("expired", entry{value: "a", expiresAt: time.Now().Add(-time.Second)}, 0, 0, 0, nil)
+		s.set("live", entry{value: "b"}, 0, 0, 0, nil)
+
+		Convey("When removeExpired is called", func() {
+			evicted := s.removeExpired(time.Now(), 0, nil)
+
+			Convey("Then only the expired entry is removed", func() {
+				So(evicted, ShouldEqual, 1)
+
+				_, foundExpired, _, _ := s.get("expired", time.Now(), 0, nil)
+				So(foundExpired, ShouldBeFalse)
+
+				_, foundLive, _, _ := s.get("live", time.Now(), 0, nil)
+				So(foundLive, ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestShardSetEvictionCallback(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a shard with a max of 1 entry and an onEvict callback", t, func() {
+		s := newShard()
+		var evictedKeys []string
+		onEvict := func(key string, value entry) {
+			evictedKeys = append(evictedKeys, key)
+		}
+
+		s.set("a", entry{value: "a"}, 0, 1, 0, nil)
+
+		Convey("When a second entry is set", func() {
+			s.set("b", entry{value: "b"}, 0, 1, 0, onEvict)
+
+			Convey("Then the callback is invoked for the evicted entry", func() {
+				So(evictedKeys, ShouldResemble, []string{"a"})
+			})
+		})
+	})
+}
+
+func TestShardDelete(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a shard containing an entry", t, func() {
+		s := newShard()
+		s.set("a", entry{value: "a"}, 0, 0, 0, nil)
+
+		Convey("When delete is called", func() {
+			value, found := s.delete("a")
+
+			Convey("Then the entry's value is returned and it is removed", func() {
+				So(found, ShouldBeTrue)
+				So(value.value, ShouldEqual, "a")
+
+				_, stillFound, _, _ := s.get("a", time.Now(), 0, nil)
+				So(stillFound, ShouldBeFalse)
+			})
+		})
+
+		Convey("When delete is called for a key that isn't present", func() {
+			_, found := s.delete("missing")
+
+			Convey("Then found is false", func() {
+				So(found, ShouldBeFalse)
+			})
+		})
+	})
+}
@@ -0,0 +1,119 @@
+// Package metrics provides a Prometheus-backed implementation of
+// dpcache.MetricsRecorder, so a service can expose cache hit/miss rates,
+// update durations and entry counts alongside its other instrumentation.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	dpcache "github.com/ONSdigital/dp-cache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector records cache events as Prometheus metrics, labelled by the
+// cache key so individual update functions can be distinguished on a
+// dashboard or alert.
+//
+// The per-key label is a cardinality hazard for a cache with many distinct
+// keys, e.g. one driving Cache.GetTyped for thousands of individually-keyed
+// entities - only use this Collector as-is with a small, bounded key set; a
+// high-cardinality cache needs a MetricsRecorder that aggregates instead of
+// labelling by key.
+type Collector struct {
+	hits           *prometheus.CounterVec
+	misses         *prometheus.CounterVec
+	updateDuration *prometheus.HistogramVec
+	updateErrors   *prometheus.CounterVec
+	entries        prometheus.Gauge
+}
+
+// ensure Collector satisfies dpcache.MetricsRecorder at compile time.
+var _ dpcache.MetricsRecorder = (*Collector)(nil)
+
+// NewCollector creates a Collector with its metrics namespaced under
+// "dpcache". Call Register to expose them on a Prometheus registerer.
+func NewCollector() *Collector {
+	return &Collector{
+		hits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dpcache",
+			Name:      "hits_total",
+			Help:      "Total number of cache hits, by key.",
+		}, []string{"key"}),
+		misses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dpcache",
+			Name:      "misses_total",
+			Help:      "Total number of cache misses, by key.",
+		}, []string{"key"}),
+		updateDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "dpcache",
+			Name:      "update_duration_seconds",
+			Help:      "Time taken for an update function to refresh its key.",
+		}, []string{"key"}),
+		updateErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dpcache",
+			Name:      "update_errors_total",
+			Help:      "Total number of failed update function calls, by key.",
+		}, []string{"key"}),
+		entries: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "dpcache",
+			Name:      "entries",
+			Help:      "Current number of entries held in the cache.",
+		}),
+	}
+}
+
+// Register registers the collector's metrics with registerer.
+func (c *Collector) Register(registerer prometheus.Registerer) error {
+	for _, collector := range []prometheus.Collector{c.hits, c.misses, c.updateDuration, c.updateErrors, c.entries} {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Hit implements dpcache.MetricsRecorder.
+func (c *Collector) Hit(key string) {
+	c.hits.WithLabelValues(key).Inc()
+}
+
+// Miss implements dpcache.MetricsRecorder.
+func (c *Collector) Miss(key string) {
+	c.misses.WithLabelValues(key).Inc()
+}
+
+// UpdateDuration implements dpcache.MetricsRecorder.
+func (c *Collector) UpdateDuration(key string, d time.Duration) {
+	c.updateDuration.WithLabelValues(key).Observe(d.Seconds())
+}
+
+// UpdateError implements dpcache.MetricsRecorder.
+func (c *Collector) UpdateError(key string) {
+	c.updateErrors.WithLabelValues(key).Inc()
+}
+
+// Entries implements dpcache.MetricsRecorder.
+func (c *Collector) Entries(n int) {
+	c.entries.Set(float64(n))
+}
+
+// NewCacheWithMetrics creates an in-memory dpcache.Cache with a Collector
+// wired in as its Config.Metrics, registering it with registerer. This is a
+// convenience for the common case of wanting both in one call; services
+// that need finer control can construct and register a Collector themselves
+// and set it on Config.Metrics directly.
+func NewCacheWithMetrics(ctx context.Context, config dpcache.Config, registerer prometheus.Registerer) (*dpcache.Cache, *Collector, error) {
+	collector := NewCollector()
+	if err := collector.Register(registerer); err != nil {
+		return nil, nil, err
+	}
+
+	config.Metrics = collector
+	c, err := dpcache.NewCache(ctx, config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return c, collector, nil
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	dpcache "github.com/ONSdigital/dp-cache"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		t.Fatalf("failed to read metric: %v", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a registered Collector", t, func() {
+		registry := prometheus.NewRegistry()
+		collector := NewCollector()
+		err := collector.Register(registry)
+		So(err, ShouldBeNil)
+
+		Convey("When Hit and Miss are recorded for a key", func() {
+			collector.Hit("key")
+			collector.Miss("key")
+
+			Convey("Then the corresponding counters are incremented", func() {
+				So(counterValue(t, collector.hits.WithLabelValues("key")), ShouldEqual, 1)
+				So(counterValue(t, collector.misses.WithLabelValues("key")), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When an update error is recorded for a key", func() {
+			collector.UpdateError("key")
+
+			Convey("Then the update error counter is incremented", func() {
+				So(counterValue(t, collector.updateErrors.WithLabelValues("key")), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When Entries is called", func() {
+			collector.Entries(5)
+
+			Convey("Then the entries gauge reflects the given value", func() {
+				var m dto.Metric
+				So(collector.entries.Write(&m), ShouldBeNil)
+				So(m.GetGauge().GetValue(), ShouldEqual, 5)
+			})
+		})
+
+		Convey("When registering the same collector again", func() {
+			err := collector.Register(registry)
+
+			Convey("Then an already-registered error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestNewCacheWithMetrics(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a valid config and registerer", t, func() {
+		registry := prometheus.NewRegistry()
+		config := dpcache.Config{}
+
+		Convey("When NewCacheWithMetrics is called", func() {
+			c, collector, err := NewCacheWithMetrics(ctx, config, registry)
+
+			Convey("Then a cache and collector are returned, and hits/misses are recorded", func() {
+				So(err, ShouldBeNil)
+				So(c, ShouldNotBeNil)
+				So(collector, ShouldNotBeNil)
+
+				c.Set("key", "value")
+				_, _ = c.Get("key")
+				_, _ = c.Get("missing")
+
+				So(counterValue(t, collector.hits.WithLabelValues("key")), ShouldEqual, 1)
+				So(counterValue(t, collector.misses.WithLabelValues("missing")), ShouldEqual, 1)
+
+				var m dto.Metric
+				So(collector.entries.Write(&m), ShouldBeNil)
+				So(m.GetGauge().GetValue(), ShouldEqual, 1)
+			})
+		})
+	})
+}
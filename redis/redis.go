@@ -0,0 +1,191 @@
+// Package redis provides a distributed, Redis-backed implementation of
+// dpcache.Cacher. Unlike the in-memory cache, a redis.Cache can be shared by
+// multiple replicas of a service so they see the same cached view of an
+// upstream instead of each polling it independently.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	dpcache "github.com/ONSdigital/dp-cache"
+	"github.com/ONSdigital/log.go/v2/log"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Cache contains all the information to start, update and close a
+// Redis-backed cache.
+type Cache struct {
+	client         *goredis.Client
+	options        dpcache.RedisOptions
+	updateInterval *time.Duration
+	close          chan struct{}
+	mu             sync.Mutex
+	updateFuncs    map[string]func() (interface{}, error)
+
+	// started records whether StartUpdates actually launched the update
+	// loop goroutine, so Close knows whether there's a receiver on close -
+	// StartUpdates returns early without starting it when no update funcs
+	// are registered, and a Cache used purely for Get/Set never calls
+	// StartUpdates at all.
+	started bool
+}
+
+// ensure Cache satisfies dpcache.Cacher at compile time.
+var _ dpcache.Cacher = (*Cache)(nil)
+
+// NewCache creates a redis-backed cache using config.RedisOptions to connect
+// to a Redis instance, and config.UpdateInterval to control how often
+// registered update functions are refreshed.
+func NewCache(ctx context.Context, config dpcache.Config) (*Cache, error) {
+	if config.RedisOptions == nil {
+		err := fmt.Errorf("redis options must be provided for the redis cache backend")
+		log.Error(ctx, "invalid redis cache config", err)
+		return nil, err
+	}
+
+	if config.UpdateInterval != nil && *config.UpdateInterval <= 0 {
+		err := fmt.Errorf("cache update interval duration is less than or equal to 0")
+		log.Error(ctx, "invalid cache update interval given", err)
+		return nil, err
+	}
+
+	client := goredis.NewClient(&goredis.Options{
+		Addr:     config.RedisOptions.Addr,
+		Password: config.RedisOptions.Password,
+		DB:       config.RedisOptions.DB,
+	})
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return &Cache{
+		client:         client,
+		options:        *config.RedisOptions,
+		updateInterval: config.UpdateInterval,
+		close:          make(chan struct{}),
+		updateFuncs:    make(map[string]func() (interface{}, error)),
+	}, nil
+}
+
+func (dc *Cache) prefixedKey(key string) string {
+	return dc.options.Prefix + key
+}
+
+// Get retrieves and JSON-decodes the value stored for key. Values are
+// decoded generically, so callers that need a concrete type should use
+// mapstructure or re-marshal/unmarshal into it, the same way they would for
+// any other value that has crossed an encoding boundary.
+func (dc *Cache) Get(key string) (interface{}, bool) {
+	raw, err := dc.client.Get(context.Background(), dc.prefixedKey(key)).Bytes()
+	if err != nil {
+		return nil, false
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, false
+	}
+
+	return value, true
+}
+
+// Set JSON-encodes data and stores it in redis against key, expiring it
+// after RedisOptions.TTL (zero means no expiry).
+func (dc *Cache) Set(key string, data interface{}) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		log.Error(context.Background(), "failed to marshal value for redis cache", err, log.Data{"key": key})
+		return
+	}
+
+	if err := dc.client.Set(context.Background(), dc.prefixedKey(key), encoded, dc.options.TTL).Err(); err != nil {
+		log.Error(context.Background(), "failed to set value in redis cache", err, log.Data{"key": key})
+	}
+}
+
+// Close stops the background updates, started with StartUpdates, and closes
+// the underlying redis client.
+func (dc *Cache) Close() {
+	dc.mu.Lock()
+	started := dc.started
+	dc.updateFuncs = make(map[string]func() (interface{}, error))
+	dc.mu.Unlock()
+
+	if started {
+		dc.close <- struct{}{}
+	}
+
+	if err := dc.client.Close(); err != nil {
+		log.Error(context.Background(), "failed to close redis client", err)
+	}
+}
+
+// AddUpdateFunc adds an update function to the cache for a specific data
+// corresponding to the `key` passed to the function. This update function
+// will then be triggered once or at every fixed interval as per the prior
+// setup of the Cache.
+func (dc *Cache) AddUpdateFunc(key string, updateFunc func() (interface{}, error)) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.updateFuncs[key] = updateFunc
+}
+
+// UpdateContent calls all the update functions with a key value stored in
+// the Cache to update the relevant data with the same key values.
+func (dc *Cache) UpdateContent(_ context.Context) error {
+	dc.mu.Lock()
+	updateFuncs := make(map[string]func() (interface{}, error), len(dc.updateFuncs))
+	for key, updateFunc := range dc.updateFuncs {
+		updateFuncs[key] = updateFunc
+	}
+	dc.mu.Unlock()
+
+	for key, updateFunc := range updateFuncs {
+		updatedContent, err := updateFunc()
+		if err != nil {
+			return fmt.Errorf("failed to update redis cache for %s. error: %v", key, err)
+		}
+		dc.Set(key, updatedContent)
+	}
+	return nil
+}
+
+// StartUpdates informs the cache to start updating the cache data at every
+// update interval which was configured when setting up the cache.
+func (dc *Cache) StartUpdates(ctx context.Context, errorChannel chan error) {
+	dc.mu.Lock()
+	hasUpdateFuncs := len(dc.updateFuncs) > 0
+	dc.mu.Unlock()
+
+	if !hasUpdateFuncs || dc.updateInterval == nil {
+		return
+	}
+
+	dc.mu.Lock()
+	dc.started = true
+	dc.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(*dc.updateInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := dc.UpdateContent(ctx); err != nil {
+					log.Error(ctx, err.Error(), err)
+					errorChannel <- err
+				}
+			case <-dc.close:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,213 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dpcache "github.com/ONSdigital/dp-cache"
+	"github.com/alicebob/miniredis/v2"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func getTestConfig(t *testing.T) (dpcache.Config, *miniredis.Miniredis) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+
+	return dpcache.Config{
+		RedisOptions: &dpcache.RedisOptions{
+			Addr:   mr.Addr(),
+			Prefix: "test:",
+		},
+	}, mr
+}
+
+func TestNewCache(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given valid redis options", t, func() {
+		config, mr := getTestConfig(t)
+		defer mr.Close()
+
+		Convey("When NewCache is called", func() {
+			testCache, err := NewCache(ctx, config)
+
+			Convey("Then a cache object should be successfully returned", func() {
+				So(testCache, ShouldNotBeNil)
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given no redis options", t, func() {
+		config := dpcache.Config{}
+
+		Convey("When NewCache is called", func() {
+			testCache, err := NewCache(ctx, config)
+
+			Convey("Then an error should be returned", func() {
+				So(testCache, ShouldBeNil)
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestGetAndSet(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a redis cache", t, func() {
+		config, mr := getTestConfig(t)
+		defer mr.Close()
+
+		testCache, err := NewCache(ctx, config)
+		So(err, ShouldBeNil)
+
+		Convey("When Set is called followed by Get", func() {
+			testCache.Set("string", "test")
+
+			Convey("Then the stored value should be retrievable", func() {
+				value, ok := testCache.Get("string")
+				So(ok, ShouldBeTrue)
+				So(value, ShouldEqual, "test")
+			})
+		})
+
+		Convey("When Get is called for a key that was never set", func() {
+			value, ok := testCache.Get("missing")
+
+			Convey("Then ok should be false", func() {
+				So(ok, ShouldBeFalse)
+				So(value, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestUpdateContent(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache with an update function", t, func() {
+		config, mr := getTestConfig(t)
+		defer mr.Close()
+
+		testCache, err := NewCache(ctx, config)
+		So(err, ShouldBeNil)
+
+		testCache.AddUpdateFunc("key", func() (interface{}, error) {
+			return "updated", nil
+		})
+
+		Convey("When UpdateContent is called", func() {
+			err := testCache.UpdateContent(ctx)
+
+			Convey("Then no error should be returned and the value should be updated", func() {
+				So(err, ShouldBeNil)
+
+				value, ok := testCache.Get("key")
+				So(ok, ShouldBeTrue)
+				So(value, ShouldEqual, "updated")
+			})
+		})
+	})
+}
+
+func TestStartUpdates(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	errorChan := make(chan error, 1)
+
+	Convey("Given a cache with update interval set and an update function", t, func() {
+		config, mr := getTestConfig(t)
+		defer mr.Close()
+
+		updateCacheInterval := 10 * time.Millisecond
+		config.UpdateInterval = &updateCacheInterval
+
+		testCache, err := NewCache(ctx, config)
+		So(err, ShouldBeNil)
+
+		count := 0
+		testCache.AddUpdateFunc("key", func() (interface{}, error) {
+			count++
+			return count, nil
+		})
+
+		Convey("When StartUpdates is called", func() {
+			go testCache.StartUpdates(ctx, errorChan)
+
+			Convey("Then the cache value should be refreshed periodically", func() {
+				time.Sleep(updateCacheInterval * 3)
+
+				value, ok := testCache.Get("key")
+				So(ok, ShouldBeTrue)
+				So(value, ShouldBeGreaterThan, 0)
+
+				testCache.Close()
+			})
+		})
+	})
+}
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a cache with an update interval but no update functions registered", t, func() {
+		config, mr := getTestConfig(t)
+		defer mr.Close()
+
+		updateCacheInterval := time.Hour
+		config.UpdateInterval = &updateCacheInterval
+
+		testCache, err := NewCache(ctx, config)
+		So(err, ShouldBeNil)
+
+		testCache.StartUpdates(ctx, make(chan error, 1))
+
+		Convey("When Close is called", func() {
+			done := make(chan struct{})
+			go func() {
+				testCache.Close()
+				close(done)
+			}()
+
+			Convey("Then it returns without blocking on an update loop that was never started", func() {
+				select {
+				case <-done:
+				case <-time.After(time.Second):
+					t.Fatal("Close blocked sending to close with no update loop goroutine running")
+				}
+			})
+		})
+	})
+
+	Convey("Given a cache that StartUpdates was never called on", t, func() {
+		config, mr := getTestConfig(t)
+		defer mr.Close()
+
+		testCache, err := NewCache(ctx, config)
+		So(err, ShouldBeNil)
+
+		Convey("When Close is called", func() {
+			done := make(chan struct{})
+			go func() {
+				testCache.Close()
+				close(done)
+			}()
+
+			Convey("Then it returns without blocking", func() {
+				select {
+				case <-done:
+				case <-time.After(time.Second):
+					t.Fatal("Close blocked waiting for an update loop that was never started")
+				}
+			})
+		})
+	})
+}
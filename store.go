@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"time"
+)
+
+// Store abstracts the backend a Cache writes through to when Config.Store is
+// set, so multiple replicas of a service can share one cached view of an
+// upstream instead of each polling it independently, the same goal as the
+// standalone redis Cacher in the `redis` subpackage - but applied underneath
+// a single Cache rather than replacing it. Values cross the Store boundary
+// as bytes, already encoded/decoded via Config.Codec.
+//
+// Range exists for callers that want to iterate a shared Store directly (for
+// example to warm a freshly started replica's local cache); Cache itself
+// never calls it.
+type Store interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(key string) error
+	Range(fn func(key string, value []byte) bool)
+	Close() error
+}
+
+// Codec encodes/decodes values crossing a Config.Store boundary.
+type Codec interface {
+	Encode(value interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
+
+// JSONCodec encodes values as JSON. It's the Codec used when Config.Store is
+// set without an explicit Config.Codec.
+type JSONCodec struct{}
+
+// Encode marshals value to JSON.
+func (JSONCodec) Encode(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// Decode unmarshals JSON into a generic interface{}, the same way
+// encoding/json represents any unknown shape (map[string]interface{},
+// []interface{}, float64 for numbers, and so on).
+func (JSONCodec) Decode(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// GobCodec encodes values using encoding/gob. Unlike JSONCodec, a value
+// decoded through GobCodec round-trips as its original concrete type rather
+// than JSON's generic representation, but the caller must gob.Register any
+// concrete type it stores, other than the basic types (string, int, float64
+// and the like) that encoding/gob registers itself, before it can be decoded
+// back out of an interface{}.
+type GobCodec struct{}
+
+// Encode gob-encodes value.
+func (GobCodec) Encode(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode gob-decodes data into value's original concrete type, which, unless
+// it is one of gob's built-in basic types, must have been registered with
+// gob.Register by the caller.
+func (GobCodec) Decode(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
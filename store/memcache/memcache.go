@@ -0,0 +1,76 @@
+// Package memcache provides a memcache-backed implementation of
+// dpcache.Store, so a Cache's Config.Store can be shared across replicas of
+// a service. This Store only moves bytes already encoded by Config.Codec -
+// it doesn't know or care what they represent.
+package memcache
+
+import (
+	"time"
+
+	dpcache "github.com/ONSdigital/dp-cache"
+	gomemcache "github.com/bradfitz/gomemcache/memcache"
+)
+
+// Store is a memcache-backed implementation of dpcache.Store.
+type Store struct {
+	client *gomemcache.Client
+	prefix string
+}
+
+// ensure Store satisfies dpcache.Store at compile time.
+var _ dpcache.Store = (*Store)(nil)
+
+// NewStore creates a memcache-backed Store using options to connect to one
+// or more memcache instances.
+func NewStore(options dpcache.MemcacheOptions) *Store {
+	return &Store{client: gomemcache.New(options.Addrs...), prefix: options.Prefix}
+}
+
+func (s *Store) prefixedKey(key string) string {
+	return s.prefix + key
+}
+
+// Get returns the value stored for key, if present.
+func (s *Store) Get(key string) ([]byte, bool) {
+	item, err := s.client.Get(s.prefixedKey(key))
+	if err != nil {
+		return nil, false
+	}
+	return item.Value, true
+}
+
+// Set stores value against key, expiring it after ttl. A ttl of 0 means the
+// value never expires.
+func (s *Store) Set(key string, value []byte, ttl time.Duration) error {
+	// memcache reads an expiration of 0 as "never" and one over 30 days as an
+	// absolute Unix time, so round a sub-second ttl up to one second and pass
+	// a long ttl as the time it expires at.
+	expiration := int32(ttl.Seconds())
+	switch {
+	case ttl > 0 && expiration == 0:
+		expiration = 1
+	case expiration > 30*24*60*60:
+		expiration = int32(time.Now().Add(ttl).Unix())
+	}
+
+	return s.client.Set(&gomemcache.Item{
+		Key:        s.prefixedKey(key),
+		Value:      value,
+		Expiration: expiration,
+	})
+}
+
+// Delete removes key from memcache.
+func (s *Store) Delete(key string) error {
+	return s.client.Delete(s.prefixedKey(key))
+}
+
+// Range is a no-op: memcache has no API to enumerate keys, so a Store backed
+// by it can't be iterated.
+func (s *Store) Range(_ func(key string, value []byte) bool) {}
+
+// Close is a no-op: the memcache client holds no resources that need
+// releasing.
+func (s *Store) Close() error {
+	return nil
+}
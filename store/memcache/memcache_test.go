@@ -0,0 +1,206 @@
+package memcache
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	dpcache "github.com/ONSdigital/dp-cache"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeMemcached is a minimal in-process server implementing just enough of
+// the memcache text protocol (set/get/delete) for Store to be exercised
+// against a real connection, since no memcache server is available in this
+// test environment.
+type fakeMemcached struct {
+	listener net.Listener
+	mu       sync.Mutex
+	items    map[string][]byte
+}
+
+func startFakeMemcached(t *testing.T) *fakeMemcached {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start fake memcached: %v", err)
+	}
+
+	f := &fakeMemcached{listener: listener, items: make(map[string][]byte)}
+	go f.serve()
+	return f
+}
+
+func (f *fakeMemcached) addr() string {
+	return f.listener.Addr().String()
+}
+
+func (f *fakeMemcached) close() {
+	_ = f.listener.Close()
+}
+
+func (f *fakeMemcached) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeMemcached) handle(conn net.Conn) {
+	defer conn.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "set":
+			size, _ := strconv.Atoi(fields[4])
+			data := make([]byte, size+2)
+			if _, err := readFull(rw, data); err != nil {
+				return
+			}
+
+			f.mu.Lock()
+			f.items[fields[1]] = data[:size]
+			f.mu.Unlock()
+
+			rw.WriteString("STORED\r\n")
+		case "get", "gets":
+			f.mu.Lock()
+			value, ok := f.items[fields[1]]
+			f.mu.Unlock()
+
+			if ok {
+				rw.WriteString("VALUE " + fields[1] + " 0 " + strconv.Itoa(len(value)) + "\r\n")
+				rw.Write(value)
+				rw.WriteString("\r\n")
+			}
+			rw.WriteString("END\r\n")
+		case "delete":
+			f.mu.Lock()
+			_, ok := f.items[fields[1]]
+			delete(f.items, fields[1])
+			f.mu.Unlock()
+
+			if ok {
+				rw.WriteString("DELETED\r\n")
+			} else {
+				rw.WriteString("NOT_FOUND\r\n")
+			}
+		default:
+			rw.WriteString("ERROR\r\n")
+		}
+		rw.Flush()
+	}
+}
+
+func readFull(rw *bufio.ReadWriter, buf []byte) (int, error) {
+	read := 0
+	for read < len(buf) {
+		n, err := rw.Read(buf[read:])
+		read += n
+		if err != nil {
+			return read, err
+		}
+	}
+	return read, nil
+}
+
+func getTestOptions(t *testing.T) (dpcache.MemcacheOptions, *fakeMemcached) {
+	f := startFakeMemcached(t)
+	return dpcache.MemcacheOptions{Addrs: []string{f.addr()}, Prefix: "test:"}, f
+}
+
+func TestGetSetDelete(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a memcache-backed store", t, func() {
+		options, f := getTestOptions(t)
+		defer f.close()
+
+		store := NewStore(options)
+
+		Convey("When Set is called followed by Get", func() {
+			So(store.Set("key", []byte("value"), 0), ShouldBeNil)
+
+			Convey("Then the stored value should be retrievable", func() {
+				value, ok := store.Get("key")
+				So(ok, ShouldBeTrue)
+				So(string(value), ShouldEqual, "value")
+			})
+		})
+
+		Convey("When Get is called for a key that was never set", func() {
+			value, ok := store.Get("missing")
+
+			Convey("Then ok should be false", func() {
+				So(ok, ShouldBeFalse)
+				So(value, ShouldBeNil)
+			})
+		})
+
+		Convey("When Delete is called", func() {
+			So(store.Set("key", []byte("value"), 0), ShouldBeNil)
+			So(store.Delete("key"), ShouldBeNil)
+
+			Convey("Then the key is no longer present", func() {
+				_, ok := store.Get("key")
+				So(ok, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestRangeIsANoOp(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a memcache-backed store with a key set", t, func() {
+		options, f := getTestOptions(t)
+		defer f.close()
+
+		store := NewStore(options)
+		So(store.Set("key", []byte("value"), 0), ShouldBeNil)
+
+		Convey("When Range is called", func() {
+			calls := 0
+			store.Range(func(key string, value []byte) bool {
+				calls++
+				return true
+			})
+
+			Convey("Then fn is never called, since memcache can't enumerate keys", func() {
+				So(calls, ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a memcache-backed store", t, func() {
+		options, f := getTestOptions(t)
+		defer f.close()
+
+		store := NewStore(options)
+
+		Convey("When Close is called", func() {
+			Convey("Then no error is returned", func() {
+				So(store.Close(), ShouldBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,96 @@
+// Package memory provides an in-memory implementation of dpcache.Store,
+// useful for composing a Cache's Config.Store in tests, or as a starting
+// point before a service has a real shared backend to point at.
+package memory
+
+import (
+	"sync"
+	"time"
+
+	dpcache "github.com/ONSdigital/dp-cache"
+)
+
+// entry holds a stored value alongside its expiry. A zero expiresAt means
+// the value never expires.
+type entry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// Store is an in-memory, mutex-guarded implementation of dpcache.Store.
+type Store struct {
+	mu    sync.Mutex
+	items map[string]entry
+}
+
+// ensure Store satisfies dpcache.Store at compile time.
+var _ dpcache.Store = (*Store)(nil)
+
+// NewStore creates an empty in-memory Store.
+func NewStore() *Store {
+	return &Store{items: make(map[string]entry)}
+}
+
+// Get returns the value stored for key, if present and not expired.
+func (s *Store) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	if !e.expiresAt.IsZero() && time.Now().After(e.expiresAt) {
+		delete(s.items, key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Set stores value against key, expiring it after ttl. A ttl of 0 means the
+// value never expires.
+func (s *Store) Set(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := entry{value: value}
+	if ttl != 0 {
+		e.expiresAt = time.Now().Add(ttl)
+	}
+	s.items[key] = e
+	return nil
+}
+
+// Delete removes key from the store.
+func (s *Store) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, key)
+	return nil
+}
+
+// Range calls fn for every non-expired key currently in the store, stopping
+// early if fn returns false.
+func (s *Store) Range(fn func(key string, value []byte) bool) {
+	s.mu.Lock()
+	now := time.Now()
+	items := make(map[string][]byte, len(s.items))
+	for key, e := range s.items {
+		if !e.expiresAt.IsZero() && now.After(e.expiresAt) {
+			continue
+		}
+		items[key] = e.value
+	}
+	s.mu.Unlock()
+
+	for key, value := range items {
+		if !fn(key, value) {
+			return
+		}
+	}
+}
+
+// Close is a no-op: Store holds no resources that need releasing.
+func (s *Store) Close() error {
+	return nil
+}
@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given an empty store", t, func() {
+		store := NewStore()
+
+		Convey("When Set is called followed by Get", func() {
+			So(store.Set("key", []byte("value"), 0), ShouldBeNil)
+
+			Convey("Then the stored value should be retrievable", func() {
+				value, ok := store.Get("key")
+				So(ok, ShouldBeTrue)
+				So(string(value), ShouldEqual, "value")
+			})
+		})
+
+		Convey("When Get is called for a key that was never set", func() {
+			value, ok := store.Get("missing")
+
+			Convey("Then ok should be false", func() {
+				So(ok, ShouldBeFalse)
+				So(value, ShouldBeNil)
+			})
+		})
+
+		Convey("When Set is called with a short ttl", func() {
+			So(store.Set("key", []byte("value"), time.Millisecond), ShouldBeNil)
+			time.Sleep(5 * time.Millisecond)
+
+			Convey("Then Get treats it as expired", func() {
+				_, ok := store.Get("key")
+				So(ok, ShouldBeFalse)
+			})
+		})
+
+		Convey("When Delete is called", func() {
+			So(store.Set("key", []byte("value"), 0), ShouldBeNil)
+			So(store.Delete("key"), ShouldBeNil)
+
+			Convey("Then the key is no longer present", func() {
+				_, ok := store.Get("key")
+				So(ok, ShouldBeFalse)
+			})
+		})
+
+		Convey("When Range is called over several keys", func() {
+			So(store.Set("a", []byte("1"), 0), ShouldBeNil)
+			So(store.Set("b", []byte("2"), 0), ShouldBeNil)
+
+			seen := make(map[string]string)
+			store.Range(func(key string, value []byte) bool {
+				seen[key] = string(value)
+				return true
+			})
+
+			Convey("Then every key is visited", func() {
+				So(seen, ShouldResemble, map[string]string{"a": "1", "b": "2"})
+			})
+		})
+
+		Convey("When Close is called", func() {
+			Convey("Then no error is returned", func() {
+				So(store.Close(), ShouldBeNil)
+			})
+		})
+	})
+}
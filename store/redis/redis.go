@@ -0,0 +1,87 @@
+// Package redis provides a Redis-backed implementation of dpcache.Store, so
+// a Cache's Config.Store can be shared across replicas of a service. Unlike
+// the sibling top-level `redis` package (a standalone dpcache.Cacher), this
+// Store only moves bytes already encoded by Config.Codec - it doesn't know
+// or care what they represent.
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	dpcache "github.com/ONSdigital/dp-cache"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Store is a Redis-backed implementation of dpcache.Store.
+type Store struct {
+	client *goredis.Client
+	prefix string
+}
+
+// ensure Store satisfies dpcache.Store at compile time.
+var _ dpcache.Store = (*Store)(nil)
+
+// NewStore creates a Redis-backed Store using options to connect to a Redis
+// instance.
+func NewStore(ctx context.Context, options dpcache.RedisOptions) (*Store, error) {
+	client := goredis.NewClient(&goredis.Options{
+		Addr:     options.Addr,
+		Password: options.Password,
+		DB:       options.DB,
+	})
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return &Store{client: client, prefix: options.Prefix}, nil
+}
+
+func (s *Store) prefixedKey(key string) string {
+	return s.prefix + key
+}
+
+// Get returns the value stored for key, if present.
+func (s *Store) Get(key string) ([]byte, bool) {
+	value, err := s.client.Get(context.Background(), s.prefixedKey(key)).Bytes()
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// Set stores value against key, expiring it after ttl. A ttl of 0 means the
+// value never expires.
+func (s *Store) Set(key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(context.Background(), s.prefixedKey(key), value, ttl).Err()
+}
+
+// Delete removes key from redis.
+func (s *Store) Delete(key string) error {
+	return s.client.Del(context.Background(), s.prefixedKey(key)).Err()
+}
+
+// Range calls fn for every key under this Store's prefix, stopping early if
+// fn returns false.
+func (s *Store) Range(fn func(key string, value []byte) bool) {
+	ctx := context.Background()
+	iter := s.client.Scan(ctx, 0, s.prefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		prefixedKey := iter.Val()
+		value, err := s.client.Get(ctx, prefixedKey).Bytes()
+		if err != nil {
+			continue
+		}
+		if !fn(strings.TrimPrefix(prefixedKey, s.prefix), value) {
+			return
+		}
+	}
+}
+
+// Close closes the underlying redis client.
+func (s *Store) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,140 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	dpcache "github.com/ONSdigital/dp-cache"
+	"github.com/alicebob/miniredis/v2"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func getTestOptions(t *testing.T) (dpcache.RedisOptions, *miniredis.Miniredis) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+
+	return dpcache.RedisOptions{Addr: mr.Addr(), Prefix: "test:"}, mr
+}
+
+func TestNewStore(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given valid redis options", t, func() {
+		options, mr := getTestOptions(t)
+		defer mr.Close()
+
+		Convey("When NewStore is called", func() {
+			store, err := NewStore(ctx, options)
+
+			Convey("Then a store object should be successfully returned", func() {
+				So(store, ShouldNotBeNil)
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given options pointing at nothing listening", t, func() {
+		options := dpcache.RedisOptions{Addr: "127.0.0.1:0"}
+
+		Convey("When NewStore is called", func() {
+			store, err := NewStore(ctx, options)
+
+			Convey("Then an error should be returned", func() {
+				So(store, ShouldBeNil)
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestGetSetDelete(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a redis-backed store", t, func() {
+		options, mr := getTestOptions(t)
+		defer mr.Close()
+
+		store, err := NewStore(ctx, options)
+		So(err, ShouldBeNil)
+
+		Convey("When Set is called followed by Get", func() {
+			So(store.Set("key", []byte("value"), 0), ShouldBeNil)
+
+			Convey("Then the stored value should be retrievable", func() {
+				value, ok := store.Get("key")
+				So(ok, ShouldBeTrue)
+				So(string(value), ShouldEqual, "value")
+			})
+		})
+
+		Convey("When Get is called for a key that was never set", func() {
+			value, ok := store.Get("missing")
+
+			Convey("Then ok should be false", func() {
+				So(ok, ShouldBeFalse)
+				So(value, ShouldBeNil)
+			})
+		})
+
+		Convey("When Delete is called", func() {
+			So(store.Set("key", []byte("value"), 0), ShouldBeNil)
+			So(store.Delete("key"), ShouldBeNil)
+
+			Convey("Then the key is no longer present", func() {
+				_, ok := store.Get("key")
+				So(ok, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestRange(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a redis-backed store with several keys set", t, func() {
+		options, mr := getTestOptions(t)
+		defer mr.Close()
+
+		store, err := NewStore(ctx, options)
+		So(err, ShouldBeNil)
+
+		So(store.Set("a", []byte("1"), 0), ShouldBeNil)
+		So(store.Set("b", []byte("2"), 0), ShouldBeNil)
+
+		Convey("When Range is called", func() {
+			seen := make(map[string]string)
+			store.Range(func(key string, value []byte) bool {
+				seen[key] = string(value)
+				return true
+			})
+
+			Convey("Then every key under this store's prefix is visited, with the prefix stripped", func() {
+				So(seen, ShouldResemble, map[string]string{"a": "1", "b": "2"})
+			})
+		})
+	})
+}
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	Convey("Given a redis-backed store", t, func() {
+		options, mr := getTestOptions(t)
+		defer mr.Close()
+
+		store, err := NewStore(ctx, options)
+		So(err, ShouldBeNil)
+
+		Convey("When Close is called", func() {
+			Convey("Then no error is returned", func() {
+				So(store.Close(), ShouldBeNil)
+			})
+		})
+	})
+}